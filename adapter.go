@@ -1,6 +1,11 @@
 package main
 
-import "github.com/pkg/errors"
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
 
 var (
 	// ErrInvalidSymbol символ не из альфавита в последовательности
@@ -12,28 +17,106 @@ type Scorer interface {
 	Score(a, b byte) int
 }
 
+// Encoder необязательное расширение Scorer, переводящее символы последовательности в плотные коды алфавита и обратно.
+// Если Scorer реализует Encoder, выравнивание производится над кодами,
+// а символы восстанавливаются только при обратном ходе.
+type Encoder interface {
+	Encode(seq string) []byte
+	Decode(code byte) byte
+	// ScoreCodes оценивает разницу между символами по их кодам
+	ScoreCodes(a, b byte) int
+}
+
+// RuneScorer оценивает разницу между символами произвольного алфавита, включая многобайтовые
+type RuneScorer interface {
+	ScoreRune(a, b rune) int
+}
+
 // Adapter вспомогательная структура для работы с последовательностями некоторого алфавита
 type Adapter interface {
 	Scorer
 	Validate(seq string) error
 }
 
+const (
+	// maxAlphabetSize число кодов MatrixAdapter, последний из них зарезервирован за invalidCode
+	maxAlphabetSize = 1 << alphabetBits
+	alphabetBits    = 5
+	// invalidCode код символа, не принадлежащего алфавиту.
+	// Его строка и столбец в таблице оценок нулевые, а любой байт, взятый по маске invalidCode, — допустимый индекс.
+	invalidCode = byte(maxAlphabetSize - 1)
+	// invalidSymbol символ, который Decode возвращает для invalidCode
+	invalidSymbol = byte('?')
+)
+
 // MatrixAdapter адаптер на основе матрицы
 type MatrixAdapter struct {
-	inner   [][]int
-	symbols map[byte]int
+	// codes код каждого байта в алфавите или invalidCode
+	codes [256]byte
+	// symbols символ алфавита по его коду
+	symbols [maxAlphabetSize]byte
+	// size число символов алфавита
+	size int
+	// table плоская таблица оценок для всех пар кодов,
+	// оценка пары (a, b) лежит по индексу a<<alphabetBits | b.
+	table [maxAlphabetSize * maxAlphabetSize]int16
+}
+
+// newMatrixAdapter строит таблицу оценок по матрице inner,
+// строки и столбцы которой пронумерованы в соответствии с symbols.
+// Алфавит должен быть меньше maxAlphabetSize символов.
+func newMatrixAdapter(inner [][]int, symbols map[byte]int) *MatrixAdapter {
+	s := &MatrixAdapter{}
+	for i := range s.codes {
+		s.codes[i] = invalidCode
+	}
+	for i := range s.symbols {
+		s.symbols[i] = invalidSymbol
+	}
+	s.size = len(symbols)
+	for symbol, code := range symbols {
+		s.codes[symbol] = byte(code)
+		s.symbols[code] = symbol
+	}
+	for i := range inner {
+		for j := range inner[i] {
+			s.table[i<<alphabetBits|j] = int16(inner[i][j])
+		}
+	}
+	return s
+}
+
+// Encode переводит последовательность в коды алфавита.
+// Символы не из алфавита переводятся в invalidCode, поэтому последовательность должна быть проверена заранее.
+func (s *MatrixAdapter) Encode(seq string) []byte {
+	res := make([]byte, len(seq))
+	for i := 0; i < len(seq); i++ {
+		res[i] = s.codes[seq[i]]
+	}
+	return res
+}
+
+// Decode возвращает символ алфавита по его коду
+func (s *MatrixAdapter) Decode(code byte) byte {
+	return s.symbols[code&invalidCode]
 }
 
 // Score оценить разницу по матрице.
-// В случае, если a или b не принадлежат алфавиту паникует.
+// Если a или b не принадлежат алфавиту, возвращает 0.
 func (s *MatrixAdapter) Score(a, b byte) int {
-	return s.inner[s.symbols[a]][s.symbols[b]]
+	return s.ScoreCodes(s.codes[a], s.codes[b])
+}
+
+// ScoreCodes оценить разницу по матрице.
+// a и b должны быть кодами алфавита, полученными с помощью Encode, для invalidCode возвращает 0.
+func (s *MatrixAdapter) ScoreCodes(a, b byte) int {
+	return int(s.table[int(a&invalidCode)<<alphabetBits|int(b&invalidCode)])
 }
 
 // Validate проверяет строку на соответсвие алфавиту
 func (s *MatrixAdapter) Validate(seq string) error {
-	for _, a := range seq {
-		if _, ok := s.symbols[byte(a)]; !ok {
+	for i := 0; i < len(seq); i++ {
+		if s.codes[seq[i]] == invalidCode {
 			return ErrInvalidSymbol
 		}
 	}
@@ -42,26 +125,26 @@ func (s *MatrixAdapter) Validate(seq string) error {
 
 // NewDNAAdapter возвращает новый объект для работы с последовательностями нуклеотидов
 func NewDNAAdapter() *MatrixAdapter {
-	return &MatrixAdapter{
-		inner: [][]int{
+	return newMatrixAdapter(
+		[][]int{
 			{5, -4, -4, -4},
 			{-4, 5, -4, -4},
 			{-4, -4, 5, -4},
 			{-4, -4, -4, 5},
 		},
-		symbols: map[byte]int{
+		map[byte]int{
 			'A': 0,
 			'T': 1,
 			'G': 2,
 			'C': 3,
 		},
-	}
+	)
 }
 
 // NewProteinAdapterBLOSUM62 возвращает новый объект для работы с последовательностями аминокислот с матрицей BLOSUM62
 func NewProteinAdapterBLOSUM62() *MatrixAdapter {
-	return &MatrixAdapter{
-		inner: [][]int{
+	return newMatrixAdapter(
+		[][]int{
 			{4, -1, -2, -2, 0, -1, -1, 0, -2, -1, -1, -1, -1, -2, -1, 1, 0, -3, -2, 0},
 			{-1, 5, 0, -2, -3, 1, 0, -2, 0, -3, -2, 2, -1, -3, -2, -1, -1, -3, -2, -3},
 			{-2, 0, 6, 1, -3, 0, 0, 0, 1, -3, -3, 0, -2, -3, -2, 1, 0, -4, -2, -3},
@@ -83,7 +166,7 @@ func NewProteinAdapterBLOSUM62() *MatrixAdapter {
 			{-2, -2, -2, -3, -2, -1, -2, -3, 2, -1, -1, -2, -1, 3, -3, -2, -2, 2, 7, -1},
 			{0, -3, -3, -3, -1, -2, -2, -3, -3, 3, 1, -2, 1, -1, -2, -2, 0, -3, -1, 4},
 		},
-		symbols: map[byte]int{
+		map[byte]int{
 			'A': 0,
 			'R': 1,
 			'N': 2,
@@ -105,13 +188,13 @@ func NewProteinAdapterBLOSUM62() *MatrixAdapter {
 			'Y': 18,
 			'V': 19,
 		},
-	}
+	)
 }
 
 // NewProteinAdapterPAM250 возвращает новый объект для работы с последовательностями аминокислот с матрицей P250
 func NewProteinAdapterPAM250() *MatrixAdapter {
-	return &MatrixAdapter{
-		inner: [][]int{
+	return newMatrixAdapter(
+		[][]int{
 			{2, -2, 0, 0, -3, 1, -1, -1, -1, -2, -1, 0, 1, 0, -2, 1, 1, 0, -6, -3},
 			{-2, 12, -5, -5, -4, -3, -3, -2, -5, -6, -5, -4, -3, -5, -4, 0, -2, -2, -8, 0},
 			{0, -5, 4, 3, -6, 1, 1, -2, 0, -4, -3, 2, -1, 2, -1, 0, 0, -2, -7, -4},
@@ -133,7 +216,7 @@ func NewProteinAdapterPAM250() *MatrixAdapter {
 			{-6, -8, -7, -7, 0, -7, -3, -5, -3, -2, -4, -4, -6, -5, 2, -2, -5, -6, 17, 0},
 			{-3, 0, -4, -4, 7, -5, 0, -1, -4, -1, -2, -2, -5, -4, -4, -3, -3, -2, 0, 10},
 		},
-		symbols: map[byte]int{
+		map[byte]int{
 			'A': 0,
 			'C': 1,
 			'D': 2,
@@ -155,24 +238,38 @@ func NewProteinAdapterPAM250() *MatrixAdapter {
 			'W': 18,
 			'Y': 19,
 		},
-	}
+	)
 }
 
 // DefaultAdapter объект по умолчанию подходит для работы с произвольными последовательностями
 type DefaultAdapter struct {
+	match    int
+	mismatch int
 }
 
-// NewDefaultAdapter возвращает новый объект DNAScorer
-func NewDefaultAdapter() *DefaultAdapter {
-	return &DefaultAdapter{}
+// NewDefaultAdapter возвращает новый объект DefaultAdapter
+// с оценками match за совпадение и mismatch за несовпадение символов
+func NewDefaultAdapter(match, mismatch int) *DefaultAdapter {
+	return &DefaultAdapter{
+		match:    match,
+		mismatch: mismatch,
+	}
 }
 
-// Score если символы сопадают — 1, иначе — 0.
+// Score если символы сопадают — match, иначе — mismatch.
 func (s *DefaultAdapter) Score(a, b byte) int {
 	if a == b {
-		return 1
+		return s.match
 	}
-	return -1
+	return s.mismatch
+}
+
+// ScoreRune если символы сопадают — match, иначе — mismatch.
+func (s *DefaultAdapter) ScoreRune(a, b rune) int {
+	if a == b {
+		return s.match
+	}
+	return s.mismatch
 }
 
 // Validate любая цепочка символов без '-' (зарезервированный символ), считается валидной
@@ -186,7 +283,7 @@ func (s *DefaultAdapter) Validate(str string) error {
 	return nil
 }
 
-func buildAdapter(mode string) Adapter {
+func buildAdapter(mode string, match, mismatch int) Adapter {
 	switch mode {
 	case dnaMode:
 		return NewDNAAdapter()
@@ -196,7 +293,33 @@ func buildAdapter(mode string) Adapter {
 		return NewProteinAdapterPAM250()
 	}
 
-	return NewDefaultAdapter()
+	return NewDefaultAdapter(match, mismatch)
+}
+
+// matrixName возвращает название матрицы оценок режима mode
+func matrixName(mode string, match, mismatch int) string {
+	switch mode {
+	case dnaMode:
+		return "DNA"
+	case proteinB62Mode:
+		return "BLOSUM62"
+	case proteinP250Mode:
+		return "PAM250"
+	}
+
+	return fmt.Sprintf("match/mismatch %+d/%+d", match, mismatch)
+}
+
+// scoreRunes оценивает пару символов с помощью scorer.
+// Многобайтовые символы могут быть оценены только RuneScorer, иначе их оценка 0.
+func scoreRunes(scorer Scorer, a, b rune) int {
+	if rs, ok := scorer.(RuneScorer); ok {
+		return rs.ScoreRune(a, b)
+	}
+	if a >= utf8.RuneSelf || b >= utf8.RuneSelf {
+		return 0
+	}
+	return scorer.Score(byte(a), byte(b))
 }
 
 func validate(a Adapter, seqs []*Sequence) error {
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrBadPairLine строка файла с оценками пар не соответствует формату "a b score"
+	ErrBadPairLine = errors.New("bad pair score line")
+)
+
+// PairAdapter адаптер, переопределяющий оценки отдельных пар символов.
+// Для пар, оценка которых не задана, используется оценка базового адаптера.
+type PairAdapter struct {
+	Adapter
+	// table плоская таблица оценок всех пар байтов: оценки базового адаптера с переопределёнными парами,
+	// оценка пары (a, b) лежит по индексу a<<8 | b.
+	table      *[256 * 256]int32
+	runeScores map[[2]rune]int
+}
+
+// NewPairAdapter возвращает новый объект PairAdapter без переопределённых пар поверх адаптера base.
+// Оценки base копируются в таблицу, поэтому base не должен меняться после создания PairAdapter.
+func NewPairAdapter(base Adapter) *PairAdapter {
+	s := &PairAdapter{
+		Adapter:    base,
+		table:      &[256 * 256]int32{},
+		runeScores: make(map[[2]rune]int),
+	}
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			s.table[a<<8|b] = int32(base.Score(byte(a), byte(b)))
+		}
+	}
+	return s
+}
+
+// Set задаёт оценку пары символов a и b.
+// Оценка симметрична: пара b и a получает ту же оценку.
+func (s *PairAdapter) Set(a, b rune, score int) error {
+	if err := s.Validate(string([]rune{a, b})); err != nil {
+		return err
+	}
+
+	s.runeScores[[2]rune{a, b}] = score
+	s.runeScores[[2]rune{b, a}] = score
+	// многобайтовые символы выравниваются только по рунам
+	if a < utf8.RuneSelf && b < utf8.RuneSelf {
+		s.table[int(a)<<8|int(b)] = int32(score)
+		s.table[int(b)<<8|int(a)] = int32(score)
+	}
+	return nil
+}
+
+// Score возвращает заданную оценку пары, если она есть, иначе оценку базового адаптера
+func (s *PairAdapter) Score(a, b byte) int {
+	return int(s.table[int(a)<<8|int(b)])
+}
+
+// ScoreRune возвращает заданную оценку пары рун, если она есть, иначе оценку базового адаптера
+func (s *PairAdapter) ScoreRune(a, b rune) int {
+	if score, ok := s.runeScores[[2]rune{a, b}]; ok {
+		return score
+	}
+	return scoreRunes(s.Adapter, a, b)
+}
+
+// ReadPairs читает оценки пар символов из r.
+// Каждая строка имеет вид "a b score", где a и b — одиночные символы.
+// Пустые строки и строки, начинающиеся с '#', пропускаются.
+func (s *PairAdapter) ReadPairs(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 3 || utf8.RuneCountInString(fields[0]) != 1 || utf8.RuneCountInString(fields[1]) != 1 {
+			return errors.Wrapf(ErrBadPairLine, "line %d", line)
+		}
+		score, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return errors.Wrapf(ErrBadPairLine, "line %d: %s", line, err)
+		}
+		a, _ := utf8.DecodeRuneInString(fields[0])
+		b, _ := utf8.DecodeRuneInString(fields[1])
+		if err := s.Set(a, b, score); err != nil {
+			return errors.Wrapf(err, "line %d", line)
+		}
+	}
+	return scanner.Err()
+}
+
+// loadPairAdapter строит PairAdapter поверх base с оценками пар из файла filename
+func loadPairAdapter(base Adapter, filename string) (*PairAdapter, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	adapter := NewPairAdapter(base)
+	if err := adapter.ReadPairs(f); err != nil {
+		return nil, errors.Wrap(err, filename)
+	}
+	return adapter, nil
+}
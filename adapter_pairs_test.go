@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PairAdapterTestSuite struct {
+	suite.Suite
+}
+
+func (s *PairAdapterTestSuite) TestReadPairs() {
+	adapter := NewPairAdapter(NewDefaultAdapter(2, -3))
+	err := adapter.ReadPairs(strings.NewReader(
+		"# похожие символы\n" +
+			"a b 1\n" +
+			"\n" +
+			"x x 5\n",
+	))
+	s.Require().NoError(err)
+
+	score := adapter.Score
+	s.Equal(1, score('a', 'b'))
+	s.Equal(1, score('b', 'a'))
+	s.Equal(5, score('x', 'x'))
+	s.Equal(2, score('a', 'a'))
+	s.Equal(-3, score('a', 'c'))
+}
+
+func (s *PairAdapterTestSuite) TestReadPairsMatrix() {
+	adapter := NewPairAdapter(NewDNAAdapter())
+	s.Require().NoError(adapter.ReadPairs(strings.NewReader("A G 2\n")))
+
+	s.Equal(2, adapter.Score('A', 'G'))
+	s.Equal(2, adapter.Score('G', 'A'))
+	s.Equal(-4, adapter.Score('A', 'T'))
+	s.Equal(5, adapter.Score('T', 'T'))
+}
+
+func (s *PairAdapterTestSuite) TestBaseScoresCopied() {
+	base := NewDNAAdapter()
+	adapter := NewPairAdapter(base)
+	for _, a := range []byte("ATGCX-") {
+		for _, b := range []byte("ATGCX-") {
+			s.Equal(base.Score(a, b), adapter.Score(a, b), "%c %c", a, b)
+		}
+	}
+}
+
+func (s *PairAdapterTestSuite) TestReadPairsErrors() {
+	for _, c := range []string{
+		"a b\n",
+		"ab c 1\n",
+		"a b x\n",
+	} {
+		adapter := NewPairAdapter(NewDefaultAdapter(1, -1))
+		s.Error(adapter.ReadPairs(strings.NewReader(c)), c)
+	}
+
+	adapter := NewPairAdapter(NewDNAAdapter())
+	s.Error(adapter.ReadPairs(strings.NewReader("A X 1\n")))
+}
+
+func TestPairAdapterSuite(t *testing.T) {
+	suite.Run(t, new(PairAdapterTestSuite))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type AdapterTestSuite struct {
+	suite.Suite
+}
+
+func (s *AdapterTestSuite) TestEncodeRoundTrip() {
+	for _, c := range []struct {
+		adapter *MatrixAdapter
+		seq     string
+	}{
+		{NewDNAAdapter(), "ATGCCGTA"},
+		{NewProteinAdapterBLOSUM62(), "ARNDCQEGHILKMFPSTWYV"},
+		{NewProteinAdapterPAM250(), "ACDEFGHIKLMNPQRSTVWY"},
+	} {
+		codes := c.adapter.Encode(c.seq)
+		decoded := make([]byte, len(codes))
+		for i, code := range codes {
+			s.Less(code, byte(maxAlphabetSize))
+			decoded[i] = c.adapter.Decode(code)
+		}
+		s.Equal(c.seq, string(decoded))
+	}
+}
+
+func (s *AdapterTestSuite) TestScore() {
+	adapter := NewDNAAdapter()
+	s.Equal(5, adapter.Score('A', 'A'))
+	s.Equal(-4, adapter.Score('A', 'C'))
+
+	codes := adapter.Encode("AC")
+	s.Equal(5, adapter.ScoreCodes(codes[0], codes[0]))
+	s.Equal(-4, adapter.ScoreCodes(codes[0], codes[1]))
+
+	b62 := NewProteinAdapterBLOSUM62()
+	s.Equal(11, b62.Score('W', 'W'))
+	s.Equal(-4, b62.Score('W', 'N'))
+}
+
+func (s *AdapterTestSuite) TestInvalidSymbol() {
+	adapter := NewDNAAdapter()
+	s.Equal(0, adapter.Score('A', 'X'))
+	s.Equal(0, adapter.Score('-', 'A'))
+
+	codes := adapter.Encode("AX")
+	s.Equal(invalidCode, codes[1])
+	s.Equal(0, adapter.ScoreCodes(codes[0], codes[1]))
+	s.Equal(invalidSymbol, adapter.Decode(codes[1]))
+	s.NotPanics(func() { adapter.ScoreCodes(0xFF, 0xFF) })
+	s.True(errors.Is(adapter.Validate("AX"), ErrInvalidSymbol))
+}
+
+// byteScorer Scorer без Encoder, как его реализовал бы внешний код
+type byteScorer struct{}
+
+func (byteScorer) Score(a, b byte) int {
+	if a == b {
+		return 1
+	}
+	return -1
+}
+
+func (s *AdapterTestSuite) TestScorerWithoutEncoder() {
+	cfg := &SequenceAlignerConfig{GapPenalty: -2, GapStartPenalty: true, GapEndPenalty: true}
+	a, b, score := NewSequenceAligner(cfg, byteScorer{}).Align("GATTACA", "GATACA")
+
+	expA, expB, expScore := NewSequenceAligner(cfg, NewDefaultAdapter(1, -1)).Align("GATTACA", "GATACA")
+	s.Equal(expA, a)
+	s.Equal(expB, b)
+	s.Equal(expScore, score)
+}
+
+func (s *AdapterTestSuite) TestPack() {
+	adapter := NewDNAAdapter()
+	seq := strings.Repeat("ATGC", 20) + "GA"
+	packed, err := adapter.Pack(seq)
+	s.Require().NoError(err)
+	s.Equal(len(seq), packed.Len())
+	s.Equal(adapter.Encode(seq), packed.Codes())
+	s.Equal(adapter.Encode("G")[0], packed.At(len(seq)-2))
+
+	_, err = adapter.Pack("ATXG")
+	s.True(errors.Is(err, ErrInvalidSymbol))
+
+	_, err = NewProteinAdapterBLOSUM62().Pack("ARN")
+	s.True(errors.Is(err, ErrAlphabetTooLarge))
+}
+
+func TestAdapterSuite(t *testing.T) {
+	suite.Run(t, new(AdapterTestSuite))
+}
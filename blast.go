@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnknownBlastColumn возвращается для неизвестной колонки табличного формата BLAST
+var ErrUnknownBlastColumn = errors.New("unknown blast column")
+
+// blastColumn колонка табличного формата BLAST
+type blastColumn struct {
+	// title название колонки в строке "# Fields:" формата blast7
+	title string
+	value func(rec *blastRecord) string
+}
+
+// blastRecord выравнивание с подсчитанными колонками.
+// Запросом считается вторая последовательность, субъектом — первая.
+type blastRecord struct {
+	*AlignmentRecord
+	// trimmed выравнивание без концевых gap
+	trimmed *AlignResult
+	stats   *AlignmentStats
+}
+
+// blastColumns поддерживаемые колонки по их именам в BLAST
+var blastColumns = map[string]blastColumn{
+	"qseqid": {"query id", func(rec *blastRecord) string { return recordName(rec.Seq2, "seq2") }},
+	"sseqid": {"subject id", func(rec *blastRecord) string { return recordName(rec.Seq1, "seq1") }},
+	"qlen":   {"query length", func(rec *blastRecord) string { return strconv.Itoa(rec.Len2) }},
+	"slen":   {"subject length", func(rec *blastRecord) string { return strconv.Itoa(rec.Len1) }},
+	"pident": {"% identity", func(rec *blastRecord) string {
+		return blastPercent(rec.stats.Identity, rec.stats.Length)
+	}},
+	"nident":   {"identical", func(rec *blastRecord) string { return strconv.Itoa(rec.stats.Identity) }},
+	"positive": {"positives", func(rec *blastRecord) string { return strconv.Itoa(rec.stats.Similarity) }},
+	"ppos": {"% positives", func(rec *blastRecord) string {
+		return blastPercent(rec.stats.Similarity, rec.stats.Length)
+	}},
+	"length":   {"alignment length", func(rec *blastRecord) string { return strconv.Itoa(rec.stats.Length) }},
+	"mismatch": {"mismatches", func(rec *blastRecord) string { return strconv.Itoa(rec.stats.Mismatch) }},
+	"gapopen":  {"gap opens", func(rec *blastRecord) string { return strconv.Itoa(rec.stats.GapOpens) }},
+	"gaps":     {"gaps", func(rec *blastRecord) string { return strconv.Itoa(rec.stats.Gaps) }},
+	"qstart":   {"q. start", func(rec *blastRecord) string { return strconv.Itoa(rec.trimmed.Start2 + 1) }},
+	"qend":     {"q. end", func(rec *blastRecord) string { return strconv.Itoa(rec.trimmed.End2) }},
+	"sstart":   {"s. start", func(rec *blastRecord) string { return strconv.Itoa(rec.trimmed.Start1 + 1) }},
+	"send":     {"s. end", func(rec *blastRecord) string { return strconv.Itoa(rec.trimmed.End1) }},
+	"qseq":     {"query seq", func(rec *blastRecord) string { return rec.trimmed.Aligned2 }},
+	"sseq":     {"subject seq", func(rec *blastRecord) string { return rec.trimmed.Aligned1 }},
+	// статистика Карлина-Альтшуля не вычисляется
+	"evalue":   {"evalue", func(rec *blastRecord) string { return "" }},
+	"bitscore": {"bit score", func(rec *blastRecord) string { return "" }},
+	"score":    {"score", func(rec *blastRecord) string { return strconv.Itoa(rec.Score) }},
+}
+
+// blastDefaultColumns стандартные 12 колонок форматов 6 и 7
+var blastDefaultColumns = []string{
+	"qseqid", "sseqid", "pident", "length", "mismatch", "gapopen",
+	"qstart", "qend", "sstart", "send", "evalue", "bitscore",
+}
+
+// blastFormatter выводит выравнивания в табличном формате BLAST (-outfmt 6 или 7)
+type blastFormatter struct {
+	cfg      *FormatConfig
+	columns  []string
+	comments bool
+}
+
+// newBlastFormatter возвращает blastFormatter с колонками columns, пустой список означает стандартные колонки.
+// Если comments, то выводятся строки комментариев формата 7.
+func newBlastFormatter(cfg *FormatConfig, columns []string, comments bool) (*blastFormatter, error) {
+	if len(columns) == 0 {
+		columns = blastDefaultColumns
+	}
+	for _, name := range columns {
+		if _, ok := blastColumns[name]; !ok {
+			return nil, errors.Wrap(ErrUnknownBlastColumn, name)
+		}
+	}
+	return &blastFormatter{cfg: cfg, columns: columns, comments: comments}, nil
+}
+
+func (f *blastFormatter) Format(w io.Writer, records []*AlignmentRecord) error {
+	bw := bufio.NewWriter(w)
+	queries := make(map[string]bool)
+	for _, rec := range records {
+		trimmed := rec.trimGaps()
+		queries[recordName(rec.Seq2, "seq2")] = true
+
+		if f.comments {
+			f.writeComments(bw, rec, trimmed.Aligned1 != "")
+		}
+		if trimmed.Aligned1 == "" {
+			continue
+		}
+
+		row := &blastRecord{
+			AlignmentRecord: rec,
+			trimmed:         trimmed,
+			stats:           ComputeStats(trimmed, f.cfg.Scorer),
+		}
+		values := make([]string, len(f.columns))
+		for i, name := range f.columns {
+			values[i] = blastColumns[name].value(row)
+		}
+		fmt.Fprintln(bw, strings.Join(values, "\t"))
+	}
+	if f.comments {
+		fmt.Fprintf(bw, "# %s processed %d queries\n", programName, len(queries))
+	}
+	return bw.Flush()
+}
+
+func (f *blastFormatter) writeComments(w io.Writer, rec *AlignmentRecord, aligned bool) {
+	titles := make([]string, len(f.columns))
+	for i, name := range f.columns {
+		titles[i] = blastColumns[name].title
+	}
+	hits := 0
+	if aligned {
+		hits = 1
+	}
+
+	fmt.Fprintf(w, "# %s\n", programName)
+	fmt.Fprintf(w, "# Query: %s\n", rec.Seq2.Description)
+	fmt.Fprintf(w, "# Subject: %s\n", rec.Seq1.Description)
+	fmt.Fprintf(w, "# Fields: %s\n", strings.Join(titles, ", "))
+	fmt.Fprintf(w, "# %d hits found\n", hits)
+}
+
+// blastPercent возвращает долю part от total в процентах с тремя знаками после запятой, как в BLAST
+func blastPercent(part, total int) string {
+	if total == 0 {
+		return "0.000"
+	}
+	return strconv.FormatFloat(100*float64(part)/float64(total), 'f', 3, 64)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type BlastTestSuite struct {
+	suite.Suite
+}
+
+func (s *BlastTestSuite) format(format string) string {
+	subject := &Sequence{Description: "subject first", Value: "TTTACGTACGTTT"}
+	query := &Sequence{Description: "query", Value: "GGACGACGTGG"}
+	aligner := NewSequenceAligner(&SequenceAlignerConfig{GapPenalty: -1, AllowLocal: true}, NewDefaultAdapter(1, -1))
+
+	formatter, err := buildFormatter(format, &FormatConfig{})
+	s.Require().NoError(err)
+	var buf bytes.Buffer
+	s.Require().NoError(formatter.Format(&buf, []*AlignmentRecord{{
+		Seq1:        subject,
+		Seq2:        query,
+		AlignResult: alignDetailed(aligner, subject.Value, query.Value),
+	}}))
+	return buf.String()
+}
+
+func (s *BlastTestSuite) TestFormat() {
+	s.Equal("query\tsubject\t87.500\t8\t0\t1\t3\t9\t4\t11\t\t\n", s.format("blast6"))
+	s.Equal("query\tsubject\tACG-ACGT\tACGTACGT\t6\n", s.format("blast6 qseqid sseqid qseq sseq score"))
+	s.Equal("# seq-aligner\n"+
+		"# Query: query\n"+
+		"# Subject: subject first\n"+
+		"# Fields: query id, q. start, q. end, gaps\n"+
+		"# 1 hits found\n"+
+		"query\t3\t9\t1\n"+
+		"# seq-aligner processed 1 queries\n", s.format("blast7 qseqid qstart qend gaps"))
+}
+
+func (s *BlastTestSuite) TestBadColumns() {
+	_, err := buildFormatter("blast6 qseqid unknown", &FormatConfig{})
+	s.Equal(ErrUnknownBlastColumn, errors.Cause(err))
+	_, err = buildFormatter("paf qseqid", &FormatConfig{})
+	s.Equal(ErrUnknownOutputFormat, errors.Cause(err))
+}
+
+func TestBlastSuite(t *testing.T) {
+	suite.Run(t, new(BlastTestSuite))
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Ошибки разбора и применения CIGAR
+var (
+	ErrBadCigar        = errors.New("cigar: bad string")
+	ErrCigarLength     = errors.New("cigar: length does not match sequence")
+	ErrCigarOutOfRange = errors.New("cigar: alignment is out of sequence")
+)
+
+// Операции CIGAR. Первая последовательность считается референсом, вторая — запросом.
+const (
+	// CigarMatch совмещение символов (совпадение или замена)
+	CigarMatch = 'M'
+	// CigarInsert символ запроса напротив gap в референсе
+	CigarInsert = 'I'
+	// CigarDelete символ референса напротив gap в запросе
+	CigarDelete = 'D'
+	// CigarSkip пропуск участка референса, выравнивается как CigarDelete
+	CigarSkip = 'N'
+	// CigarSoftClip символ запроса вне выравнивания
+	CigarSoftClip = 'S'
+	// CigarHardClip символ запроса вне выравнивания, отсутствующий в последовательности
+	CigarHardClip = 'H'
+	// CigarPadding пропуск в обеих последовательностях
+	CigarPadding = 'P'
+	// CigarEqual совпадение символов
+	CigarEqual = '='
+	// CigarDiff замена символа
+	CigarDiff = 'X'
+)
+
+// CigarOp операция CIGAR, повторённая Len раз
+type CigarOp struct {
+	Len int
+	Op  byte
+}
+
+// Cigar описание выравнивания в формате CIGAR
+type Cigar []CigarOp
+
+// NewCigar строит CIGAR по выровненным строкам res.
+// Если extended, то совпадения и замены различаются операциями '=' и 'X', иначе обе записываются как 'M'.
+// Части второй последовательности вне выравнивания записываются как soft clip.
+func NewCigar(res *AlignResult, extended bool) Cigar {
+	var c Cigar
+	c = c.push(CigarSoftClip, res.Start2)
+
+	runes1, runes2 := []rune(res.Aligned1), []rune(res.Aligned2)
+	for i := range runes1 {
+		switch {
+		case runes1[i] == gapRune:
+			c = c.push(CigarInsert, 1)
+		case runes2[i] == gapRune:
+			c = c.push(CigarDelete, 1)
+		case !extended:
+			c = c.push(CigarMatch, 1)
+		case runes1[i] == runes2[i]:
+			c = c.push(CigarEqual, 1)
+		default:
+			c = c.push(CigarDiff, 1)
+		}
+	}
+
+	return c.push(CigarSoftClip, res.Len2-res.End2)
+}
+
+// trimGaps возвращает копию res без gap на концах выравнивания.
+// Символы запроса напротив концевых gap уходят в soft clip, а символы референса — за границы выравнивания.
+func (res *AlignResult) trimGaps() *AlignResult {
+	runes1, runes2 := []rune(res.Aligned1), []rune(res.Aligned2)
+	trimmed := *res
+
+	l := 0
+	for l < len(runes1) && (runes1[l] == gapRune || runes2[l] == gapRune) {
+		if runes1[l] != gapRune {
+			trimmed.Start1++
+		}
+		if runes2[l] != gapRune {
+			trimmed.Start2++
+		}
+		l++
+	}
+	r := len(runes1)
+	for r > l && (runes1[r-1] == gapRune || runes2[r-1] == gapRune) {
+		if runes1[r-1] != gapRune {
+			trimmed.End1--
+		}
+		if runes2[r-1] != gapRune {
+			trimmed.End2--
+		}
+		r--
+	}
+
+	trimmed.Aligned1, trimmed.Aligned2 = string(runes1[l:r]), string(runes2[l:r])
+	return &trimmed
+}
+
+// withEndGaps возвращает копию res, дополненную до концов seq1 и seq2 колонками концевых gap.
+// Символы до начала выравнивания и после его конца ставятся напротив gap: сначала первой последовательности,
+// затем второй, так что выравнивание покрывает обе последовательности целиком.
+func (res *AlignResult) withEndGaps(seq1, seq2 string) *AlignResult {
+	runes1, runes2 := []rune(seq1), []rune(seq2)
+	aligned1, aligned2 := &strings.Builder{}, &strings.Builder{}
+	flank := func(part1, part2 []rune) {
+		aligned1.WriteString(string(part1))
+		aligned2.WriteString(strings.Repeat(string(gapRune), len(part1)))
+		aligned1.WriteString(strings.Repeat(string(gapRune), len(part2)))
+		aligned2.WriteString(string(part2))
+	}
+
+	flank(runes1[:res.Start1], runes2[:res.Start2])
+	aligned1.WriteString(res.Aligned1)
+	aligned2.WriteString(res.Aligned2)
+	flank(runes1[res.End1:], runes2[res.End2:])
+
+	extended := *res
+	extended.Aligned1, extended.Aligned2 = aligned1.String(), aligned2.String()
+	extended.Start1, extended.End1, extended.Len1 = 0, len(runes1), len(runes1)
+	extended.Start2, extended.End2, extended.Len2 = 0, len(runes2), len(runes2)
+	return &extended
+}
+
+// editDistance возвращает число замен и символов напротив gap в выравнивании
+func (res *AlignResult) editDistance() int {
+	runes1, runes2 := []rune(res.Aligned1), []rune(res.Aligned2)
+	n := 0
+	for i := range runes1 {
+		if runes1[i] != runes2[i] {
+			n++
+		}
+	}
+	return n
+}
+
+// matches возвращает число совпадающих символов в выравнивании
+func (res *AlignResult) matches() int {
+	runes1, runes2 := []rune(res.Aligned1), []rune(res.Aligned2)
+	n := 0
+	for i := range runes1 {
+		if runes1[i] == runes2[i] && runes1[i] != gapRune {
+			n++
+		}
+	}
+	return n
+}
+
+// unclipped возвращает CIGAR без операций soft и hard clip
+func (c Cigar) unclipped() Cigar {
+	res := make(Cigar, 0, len(c))
+	for _, op := range c {
+		if op.Op != CigarSoftClip && op.Op != CigarHardClip {
+			res = append(res, op)
+		}
+	}
+	return res
+}
+
+// push добавляет n операций op, объединяя их с последней операцией
+func (c Cigar) push(op byte, n int) Cigar {
+	if n <= 0 {
+		return c
+	}
+	if len(c) > 0 && c[len(c)-1].Op == op {
+		c[len(c)-1].Len += n
+		return c
+	}
+	return append(c, CigarOp{Len: n, Op: op})
+}
+
+// ParseCigar разбирает строку CIGAR, "*" означает пустой CIGAR
+func ParseCigar(s string) (Cigar, error) {
+	if s == "*" {
+		return nil, nil
+	}
+
+	var c Cigar
+	for len(s) > 0 {
+		i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+		if i <= 0 {
+			return nil, errors.Wrap(ErrBadCigar, s)
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil || n == 0 {
+			return nil, errors.Wrap(ErrBadCigar, s)
+		}
+
+		switch op := s[i]; op {
+		case CigarMatch, CigarInsert, CigarDelete, CigarSkip, CigarSoftClip,
+			CigarHardClip, CigarPadding, CigarEqual, CigarDiff:
+			c = append(c, CigarOp{Len: n, Op: op})
+		default:
+			return nil, errors.Wrapf(ErrBadCigar, "unknown operation %q", op)
+		}
+		s = s[i+1:]
+	}
+	return c, nil
+}
+
+// String возвращает CIGAR в текстовом виде, "*" для пустого CIGAR
+func (c Cigar) String() string {
+	if len(c) == 0 {
+		return "*"
+	}
+
+	b := &strings.Builder{}
+	for _, op := range c {
+		b.WriteString(strconv.Itoa(op.Len))
+		b.WriteByte(op.Op)
+	}
+	return b.String()
+}
+
+// Apply восстанавливает выравнивание по CIGAR, референсу seq1 и запросу seq2.
+// start1 позиция (с 0) начала выравнивания в seq1. Оценка выравнивания не вычисляется.
+// Символы запроса, отрезанные hard clip, в seq2 отсутствуют.
+func (c Cigar) Apply(seq1, seq2 string, start1 int) (*AlignResult, error) {
+	runes1, runes2 := []rune(seq1), []rune(seq2)
+	if start1 < 0 || start1 > len(runes1) {
+		return nil, errors.Wrapf(ErrCigarOutOfRange, "reference has %d symbols", len(runes1))
+	}
+	res := &AlignResult{
+		Start1: start1,
+		Len1:   len(runes1),
+		Len2:   len(runes2),
+	}
+	aligned1, aligned2 := &strings.Builder{}, &strings.Builder{}
+
+	i, j := start1, 0
+	started := false
+	for _, op := range c {
+		switch op.Op {
+		case CigarHardClip, CigarPadding:
+			continue
+		case CigarSoftClip:
+			j += op.Len
+			continue
+		}
+
+		if !started {
+			started = true
+			res.Start2 = j
+		}
+		consumes1 := op.Op != CigarInsert
+		consumes2 := op.Op != CigarDelete && op.Op != CigarSkip
+		if consumes1 && i+op.Len > len(runes1) {
+			return nil, errors.Wrapf(ErrCigarOutOfRange, "reference has %d symbols", len(runes1))
+		}
+		if consumes2 && j+op.Len > len(runes2) {
+			return nil, errors.Wrapf(ErrCigarLength, "query has %d symbols", len(runes2))
+		}
+
+		for k := 0; k < op.Len; k++ {
+			if consumes1 {
+				aligned1.WriteRune(runes1[i])
+				i++
+			} else {
+				aligned1.WriteRune(gapRune)
+			}
+			if consumes2 {
+				aligned2.WriteRune(runes2[j])
+				j++
+			} else {
+				aligned2.WriteRune(gapRune)
+			}
+		}
+		res.End1, res.End2 = i, j
+	}
+	if j != len(runes2) {
+		return nil, errors.Wrapf(ErrCigarLength, "cigar covers %d of %d query symbols", j, len(runes2))
+	}
+	if !started {
+		res.End1, res.Start2, res.End2 = start1, j, j
+	}
+
+	res.Aligned1, res.Aligned2 = aligned1.String(), aligned2.String()
+	return res, nil
+}
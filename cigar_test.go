@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type CigarTestSuite struct {
+	suite.Suite
+}
+
+func (s *CigarTestSuite) TestNewCigar() {
+	for _, c := range []struct {
+		res     AlignResult
+		exp     string
+		expExt  string
+		comment string
+	}{
+		{
+			res:     AlignResult{Aligned1: "ACGT-A", Aligned2: "AC-TTA", End1: 5, End2: 5, Len1: 5, Len2: 5},
+			exp:     "2M1D1M1I1M",
+			expExt:  "2=1D1=1I1=",
+			comment: "глобальное выравнивание",
+		},
+		{
+			res:     AlignResult{Aligned1: "ACGT", Aligned2: "AGGT", Start1: 3, End1: 7, Start2: 2, End2: 6, Len1: 10, Len2: 9},
+			exp:     "2S4M3S",
+			expExt:  "2S1=1X2=3S",
+			comment: "локальное выравнивание с soft clip",
+		},
+		{
+			res:     AlignResult{Aligned1: "пр-ивет", Aligned2: "прuивет", End1: 6, End2: 7, Len1: 6, Len2: 7},
+			exp:     "2M1I4M",
+			expExt:  "2=1I4=",
+			comment: "многобайтовые символы",
+		},
+		{
+			res:     AlignResult{},
+			exp:     "*",
+			expExt:  "*",
+			comment: "пустое выравнивание",
+		},
+	} {
+		s.Equal(c.exp, NewCigar(&c.res, false).String(), c.comment)
+		s.Equal(c.expExt, NewCigar(&c.res, true).String(), c.comment)
+	}
+}
+
+func (s *CigarTestSuite) formatCigar(ref, query string, cfg *SequenceAlignerConfig) string {
+	scorer := NewDNAAdapter()
+	var buf bytes.Buffer
+	formatter := &cigarFormatter{cfg: &FormatConfig{Scoring: &ScoringInfo{
+		GapOpen:         cfg.GapPenalty,
+		GapExtend:       cfg.GapPenalty,
+		GapStartPenalty: cfg.GapStartPenalty,
+		GapEndPenalty:   cfg.GapEndPenalty,
+		Local:           cfg.AllowLocal,
+	}}}
+	s.Require().NoError(formatter.Format(&buf, []*AlignmentRecord{{
+		Seq1:        &Sequence{Description: "r", Value: ref},
+		Seq2:        &Sequence{Description: "q", Value: query},
+		AlignResult: alignDetailed(NewSequenceAligner(cfg, scorer), ref, query),
+	}}))
+	return buf.String()
+}
+
+func (s *CigarTestSuite) TestFormatLocal() {
+	// не вошедшие в локальное выравнивание символы запроса уходят в soft clip
+	out := s.formatCigar("ACGTAC", "TTTTACGTAC", &SequenceAlignerConfig{GapPenalty: -2, AllowLocal: true})
+	s.Equal("r\t1\tq\t4S6M\t30\n", out)
+}
+
+func (s *CigarTestSuite) TestFormatGlobalEndGaps() {
+	// концевые gap глобального выравнивания остаются в CIGAR, и оценка воспроизводится по строке
+	cfg := &SequenceAlignerConfig{GapPenalty: -2, GapStartPenalty: true, GapEndPenalty: true}
+	out := s.formatCigar("TTACGTACGT", "ACGTACGTGG", cfg)
+	s.Equal("r\t1\tq\t2D8M2I\t32\n", out)
+
+	fields := strings.Split(strings.TrimSpace(out), "\t")
+	c, err := ParseCigar(fields[3])
+	s.Require().NoError(err)
+	res, err := c.Apply("TTACGTACGT", "ACGTACGTGG", 0)
+	s.Require().NoError(err)
+	s.Equal(32, Rescore(res, NewDNAAdapter(), &ScoringInfo{GapOpen: -2, GapExtend: -2, GapStartPenalty: true, GapEndPenalty: true}))
+}
+
+func (s *CigarTestSuite) TestParseCigar() {
+	for _, str := range []string{"*", "10M", "2S3=1X1D4=2S", "5H3M2I3N1P2M"} {
+		c, err := ParseCigar(str)
+		s.Require().NoError(err, str)
+		s.Equal(str, c.String())
+	}
+
+	for _, str := range []string{"M", "10", "3M2", "0M", "3Q", "-1M"} {
+		_, err := ParseCigar(str)
+		s.Equal(ErrBadCigar, errors.Cause(err), str)
+	}
+}
+
+func (s *CigarTestSuite) TestApply() {
+	const seq1, seq2 = "TTTACGTACGTTT", "GGACGACGTGG"
+	for _, cfg := range []*SequenceAlignerConfig{
+		{GapPenalty: -1},
+		{GapPenalty: -1, AllowLocal: true},
+	} {
+		exp := alignDetailed(NewSequenceAligner(cfg, NewDefaultAdapter(1, -1)), seq1, seq2)
+		for _, extended := range []bool{false, true} {
+			c, err := ParseCigar(NewCigar(exp, extended).String())
+			s.Require().NoError(err)
+			res, err := c.Apply(seq1, seq2, exp.Start1)
+			s.Require().NoError(err)
+
+			res.Score = exp.Score
+			s.Equal(exp, res, c.String())
+		}
+	}
+
+	c, _ := ParseCigar("2H3M1N2M")
+	res, err := c.Apply("ACGTAC", "ACGAC", 0)
+	s.Require().NoError(err)
+	s.Equal("ACGTAC", res.Aligned1)
+	s.Equal("ACG-AC", res.Aligned2)
+
+	_, err = c.Apply("ACGTAC", "ACGACT", 0)
+	s.Equal(ErrCigarLength, errors.Cause(err))
+	_, err = c.Apply("ACGTAC", "ACGAC", 2)
+	s.Equal(ErrCigarOutOfRange, errors.Cause(err))
+}
+
+func TestCigarSuite(t *testing.T) {
+	suite.Run(t, new(CigarTestSuite))
+}
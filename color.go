@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"unicode"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+	"github.com/pkg/errors"
+)
+
+// Ошибки выбора режима и темы раскраски
+var (
+	ErrUnknownColorMode  = errors.New("unknown color mode")
+	ErrUnknownColorTheme = errors.New("unknown color theme")
+	ErrThemeNeedsProtein = errors.New("color theme is only for protein modes")
+)
+
+// Режимы вывода цветов
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// Темы раскраски
+const (
+	matchTheme   = "match"
+	clustalTheme = "clustal"
+)
+
+// useColor сообщает, нужно ли выводить в f цвета в режиме mode.
+// В режиме auto цвета выводятся только в терминал, непустая переменная NO_COLOR запрещает их,
+// а CLICOLOR_FORCE, отличная от "0", включает цвета для любого вывода. NO_COLOR важнее CLICOLOR_FORCE.
+// getenv возвращает значение переменной окружения.
+func useColor(mode string, f *os.File, getenv func(string) string) (bool, error) {
+	switch mode {
+	case colorAlways:
+		return true, nil
+	case colorNever:
+		return false, nil
+	case colorAuto:
+		if getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		if force := getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+			return true, nil
+		}
+		return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()), nil
+	}
+	return false, errors.Wrap(ErrUnknownColorMode, mode)
+}
+
+// ColorTheme выбирает цвета символов разноцветного вывода
+type ColorTheme interface {
+	// Color возвращает цвет символа r в колонке выравнивания с символом разметки markup
+	// или nil, если символ не раскрашивается. Для строки разметки r совпадает с markup.
+	Color(r, markup rune) *color.Color
+}
+
+// buildColorTheme возвращает тему раскраски с именем name для алфавита режима mode.
+// Тема clustal раскрашивает аминокислоты, поэтому доступна только в режимах белков.
+func buildColorTheme(name, mode string) (ColorTheme, error) {
+	switch name {
+	case matchTheme:
+		return NewMatchColorTheme(), nil
+	case clustalTheme:
+		if mode != proteinB62Mode && mode != proteinP250Mode {
+			return nil, errors.Wrapf(ErrThemeNeedsProtein, "%s in mode %s", name, mode)
+		}
+		return NewClustalColorTheme(), nil
+	}
+	return nil, errors.Wrap(ErrUnknownColorTheme, name)
+}
+
+// buildOutputTheme возвращает тему раскраски с именем name для вывода в формате format.
+// Цвета использует только формат default с pretty, для остальных тема не проверяется и равна nil.
+func buildOutputTheme(name, mode, format string, pretty bool) (ColorTheme, error) {
+	if !pretty || format != defaultFormat {
+		return nil, nil
+	}
+	return buildColorTheme(name, mode)
+}
+
+// MatchColorTheme раскрашивает колонки по совпадению символов:
+// совпадения зелёным, похожие символы жёлтым, несовпадения синим, gap красным
+type MatchColorTheme struct {
+	identity *color.Color
+	similar  *color.Color
+	mismatch *color.Color
+	gap      *color.Color
+}
+
+// NewMatchColorTheme возвращает новый объект MatchColorTheme
+func NewMatchColorTheme() *MatchColorTheme {
+	return &MatchColorTheme{
+		identity: color.New(color.FgGreen),
+		similar:  color.New(color.FgYellow),
+		mismatch: color.New(color.FgBlue),
+		gap:      color.New(color.FgRed),
+	}
+}
+
+func (t *MatchColorTheme) Color(r, markup rune) *color.Color {
+	switch markup {
+	case markupGap:
+		return t.gap
+	case markupIdentity:
+		return t.identity
+	case markupSimilar:
+		return t.similar
+	}
+	return t.mismatch
+}
+
+// ClustalColorTheme раскрашивает аминокислоты по классам, как схема Clustal X:
+// гидрофобные синим, положительно заряженные красным, отрицательно заряженные пурпурным,
+// полярные зелёным, цистеин розовым, глицин оранжевым, пролин жёлтым, ароматические голубым.
+// Прочие символы, gap и строка разметки не раскрашиваются.
+type ClustalColorTheme struct {
+	classes map[rune]*color.Color
+}
+
+// NewClustalColorTheme возвращает новый объект ClustalColorTheme
+func NewClustalColorTheme() *ClustalColorTheme {
+	t := &ClustalColorTheme{classes: make(map[rune]*color.Color)}
+	for residues, c := range map[string]*color.Color{
+		"AILMFWV": color.New(color.FgBlue),
+		"KR":      color.New(color.FgRed),
+		"DE":      color.New(color.FgMagenta),
+		"NQST":    color.New(color.FgGreen),
+		"C":       color.New(color.FgHiMagenta),
+		"G":       color.New(color.FgHiYellow),
+		"P":       color.New(color.FgYellow),
+		"HY":      color.New(color.FgCyan),
+	} {
+		for _, r := range residues {
+			t.classes[r] = c
+		}
+	}
+	return t
+}
+
+func (t *ClustalColorTheme) Color(r, markup rune) *color.Color {
+	return t.classes[unicode.ToUpper(r)]
+}
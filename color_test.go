@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type ColorTestSuite struct {
+	suite.Suite
+}
+
+func (s *ColorTestSuite) TestUseColor() {
+	f, err := os.CreateTemp(s.T().TempDir(), "out")
+	s.Require().NoError(err)
+	defer f.Close()
+
+	for _, c := range []struct {
+		mode    string
+		env     map[string]string
+		exp     bool
+		comment string
+	}{
+		{mode: colorAuto, exp: false, comment: "файл не терминал"},
+		{mode: colorAuto, env: map[string]string{"CLICOLOR_FORCE": "1"}, exp: true, comment: "CLICOLOR_FORCE"},
+		{mode: colorAuto, env: map[string]string{"CLICOLOR_FORCE": "0"}, exp: false, comment: "CLICOLOR_FORCE=0"},
+		{mode: colorAuto, env: map[string]string{"CLICOLOR_FORCE": "1", "NO_COLOR": "1"}, exp: false, comment: "NO_COLOR"},
+		{mode: colorAlways, env: map[string]string{"NO_COLOR": "1"}, exp: true, comment: "always"},
+		{mode: colorNever, env: map[string]string{"CLICOLOR_FORCE": "1"}, exp: false, comment: "never"},
+	} {
+		use, err := useColor(c.mode, f, func(name string) string { return c.env[name] })
+		s.Require().NoError(err, c.comment)
+		s.Equal(c.exp, use, c.comment)
+	}
+
+	_, err = useColor("sometimes", f, os.Getenv)
+	s.Equal(ErrUnknownColorMode, errors.Cause(err))
+}
+
+func (s *ColorTestSuite) TestThemes() {
+	match, err := buildColorTheme(matchTheme, dnaMode)
+	s.Require().NoError(err)
+	s.Equal(NewMatchColorTheme().identity, match.Color('A', markupIdentity))
+	s.Equal(NewMatchColorTheme().gap, match.Color('-', markupGap))
+
+	clustal, err := buildColorTheme(clustalTheme, proteinB62Mode)
+	s.Require().NoError(err)
+	s.Equal(clustal.Color('I', markupMismatch), clustal.Color('v', markupIdentity))
+	s.NotEqual(clustal.Color('K', markupIdentity), clustal.Color('D', markupIdentity))
+	s.Nil(clustal.Color('-', markupGap))
+	s.Nil(clustal.Color(markupIdentity, markupIdentity))
+
+	for _, mode := range []string{dnaMode, defaultMode} {
+		_, err = buildColorTheme(clustalTheme, mode)
+		s.Equal(ErrThemeNeedsProtein, errors.Cause(err), mode)
+	}
+
+	// без разноцветного вывода тема не нужна и не проверяется
+	theme, err := buildOutputTheme(clustalTheme, dnaMode, samFormat, true)
+	s.NoError(err)
+	s.Nil(theme)
+	theme, err = buildOutputTheme(clustalTheme, dnaMode, defaultFormat, false)
+	s.NoError(err)
+	s.Nil(theme)
+	_, err = buildOutputTheme(clustalTheme, dnaMode, defaultFormat, true)
+	s.Equal(ErrThemeNeedsProtein, errors.Cause(err))
+
+	_, err = buildColorTheme("rainbow", defaultMode)
+	s.Equal(ErrUnknownColorTheme, errors.Cause(err))
+}
+
+func (s *ColorTestSuite) TestWritePrettyColored() {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	res := &AlignResult{Aligned1: "K-", Aligned2: "KA", End1: 1, End2: 2, Len1: 1, Len2: 2}
+	var buf bytes.Buffer
+	s.Require().NoError(WritePretty(&buf, 10, nil, NewClustalColorTheme(), res))
+	s.Equal(""+
+		"seq1:      1 \x1b[31mK\x1b[0m-      1\n"+
+		"             | \n"+
+		"seq2:      1 \x1b[31mK\x1b[0m\x1b[34mA\x1b[0m      2\n", buf.String())
+}
+
+func TestColorSuite(t *testing.T) {
+	suite.Run(t, new(ColorTestSuite))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ErrIndexCompressed возвращается при попытке индексировать сжатый файл
+var ErrIndexCompressed = errors.New("can not index compressed file")
+
+// command подкоманда, выбираемая первым аргументом командной строки
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+// commands подкоманды seq-aligner, без подкоманды выполняется выравнивание
+var commands = map[string]command{
+	"dotplot": {
+		usage: "[-word K] [-mismatches N] [-strand forward|reverse|both] [-format text|png|svg] [-size PX] [-path] <file1> [file2] draws a dot plot",
+		run:   runDotPlot,
+	},
+	"index": {
+		usage: "<file.fa>... builds samtools-compatible .fai indexes",
+		run:   runIndex,
+	},
+	"rescore": {
+		usage: "[-cigar CIGAR [-pos N]] <aligned.fa|alignment.aln|file1 [file2]> scores an existing alignment with the given options",
+		run:   runRescore,
+	},
+}
+
+// findCommand возвращает подкоманду, если ею является первый позиционный аргумент positional,
+// оставшийся после разбора флагов из args. Аргументы после "--" всегда считаются файлами,
+// так что "seq-aligner -- index" выравнивает записи файла index.
+func findCommand(args, positional []string) (command, bool) {
+	if len(positional) == 0 {
+		return command{}, false
+	}
+	if consumed := len(args) - len(positional); consumed > 0 && args[consumed-1] == "--" {
+		return command{}, false
+	}
+	cmd, ok := commands[positional[0]]
+	return cmd, ok
+}
+
+// runIndex строит индексы .fai для fasta файлов args
+func runIndex(args []string) error {
+	if len(args) == 0 {
+		return errors.New("expected fasta files to index")
+	}
+	for _, filename := range args {
+		if err := indexFastaFile(filename); err != nil {
+			// ошибки открытия и создания файлов уже содержат их имя
+			var pathErr *os.PathError
+			if errors.As(err, &pathErr) {
+				return err
+			}
+			return errors.Wrap(err, filename)
+		}
+	}
+	return nil
+}
+
+// indexFastaFile записывает индекс файла filename в filename.fai
+func indexFastaFile(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// в сжатом файле смещения индекса не позволяют читать участки напрямую
+	compressed, err := isCompressed(f)
+	if err != nil {
+		return err
+	}
+	if compressed {
+		return ErrIndexCompressed
+	}
+
+	idx, err := BuildFastaIndex(f)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(filename + faiSuffix)
+	if err != nil {
+		return err
+	}
+	if err := idx.Write(out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// printCommandsUsage выводит описание подкоманд для справки
+func printCommandsUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  seq-aligner %s %s\n", name, commands[name].usage)
+	}
+}
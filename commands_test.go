@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CommandsTestSuite struct {
+	suite.Suite
+}
+
+func (s *CommandsTestSuite) TestFindCommand() {
+	for _, c := range []struct {
+		args       []string
+		positional []string
+		exp        bool
+		comment    string
+	}{
+		{args: []string{"index", "ref.fa"}, positional: []string{"index", "ref.fa"}, exp: true},
+		{args: []string{"--mode", "dna", "dotplot", "a.fa"}, positional: []string{"dotplot", "a.fa"}, exp: true},
+		{args: []string{"a.fa", "index"}, positional: []string{"a.fa", "index"}, comment: "подкоманда только первым аргументом"},
+		{args: []string{"--", "index"}, positional: []string{"index"}, comment: "после -- только файлы"},
+		{args: []string{"--mode", "dna", "--", "rescore", "b.fa"}, positional: []string{"rescore", "b.fa"}, comment: "после -- только файлы"},
+		{args: []string{"--mode", "dna"}, comment: "нет позиционных аргументов"},
+	} {
+		_, ok := findCommand(c.args, c.positional)
+		s.Equal(c.exp, ok, c.comment)
+	}
+}
+
+func TestCommandsSuite(t *testing.T) {
+	suite.Run(t, new(CommandsTestSuite))
+}
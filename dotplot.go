@@ -0,0 +1,520 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Ошибки построения точечной диаграммы
+var (
+	ErrBadWordSize        = errors.New("dotplot: word size must be positive")
+	ErrDotPlotTooLarge    = errors.New("dotplot: sequences are too long for text output, use png or svg")
+	ErrUnknownStrand      = errors.New("unknown strand")
+	ErrUnknownPlotFormat  = errors.New("unknown plot format")
+	ErrNegativeMismatches = errors.New("dotplot: mismatch threshold must not be negative")
+	ErrTooManyMatches     = errors.New("dotplot: too many matches for svg output, use png or a longer word")
+)
+
+// Цепи, сравниваемые на точечной диаграмме
+const (
+	strandForward = "forward"
+	strandReverse = "reverse"
+	strandBoth    = "both"
+)
+
+// Форматы вывода точечной диаграммы
+const (
+	plotTextFormat = "text"
+	plotPNGFormat  = "png"
+	plotSVGFormat  = "svg"
+)
+
+const (
+	// maxTextDotPlot наибольшая длина последовательности для текстового вывода
+	maxTextDotPlot = 200
+	// defaultPlotSize наибольший размер изображения в пикселях по умолчанию
+	defaultPlotSize = 800
+	// maxSVGRuns наибольшее число отрезков в выводе SVG
+	maxSVGRuns = 100000
+)
+
+// Символы текстового вывода точечной диаграммы
+const (
+	textPlotEmpty   = '.'
+	textPlotForward = '\\'
+	textPlotReverse = '/'
+	textPlotBoth    = 'X'
+	textPlotPath    = '*'
+)
+
+// complements пары комплементарных нуклеотидов, включая неоднозначные коды IUPAC
+var complements = map[rune]rune{
+	'A': 'T', 'T': 'A', 'U': 'A', 'G': 'C', 'C': 'G', 'N': 'N',
+	'R': 'Y', 'Y': 'R', 'S': 'S', 'W': 'W', 'K': 'M', 'M': 'K',
+	'B': 'V', 'V': 'B', 'D': 'H', 'H': 'D',
+	'a': 't', 't': 'a', 'u': 'a', 'g': 'c', 'c': 'g', 'n': 'n',
+	'r': 'y', 'y': 'r', 's': 's', 'w': 'w', 'k': 'm', 'm': 'k',
+	'b': 'v', 'v': 'b', 'd': 'h', 'h': 'd',
+}
+
+// ReverseComplement возвращает обратно комплементарную последовательность нуклеотидов.
+// Символы, не являющиеся кодами нуклеотидов, не заменяются.
+func ReverseComplement(seq string) string {
+	runes := []rune(seq)
+	res := make([]rune, len(runes))
+	for i, r := range runes {
+		if c, ok := complements[r]; ok {
+			r = c
+		}
+		res[len(runes)-1-i] = r
+	}
+	return string(res)
+}
+
+// DotPlotConfig параметры построения точечной диаграммы
+type DotPlotConfig struct {
+	// Word длина сравниваемых окон
+	Word int
+	// Mismatches наибольшее число несовпадающих символов в совпавших окнах
+	Mismatches int
+	// Forward и Reverse включают сравнение первой последовательности со второй
+	// и с обратно комплементарной второй последовательностью
+	Forward bool
+	Reverse bool
+}
+
+// Dot клетка диаграммы с I-м символом первой и J-м символом второй последовательности (с 0)
+type Dot struct {
+	I int
+	J int
+}
+
+// runFunc получает ряд из count совпавших подряд окон одной диагонали,
+// первое из которых начинается с i-го символа первой и j-го символа второй последовательности (с 0)
+type runFunc func(i, j, count int)
+
+// DotPlot точечная диаграмма сравнения двух последовательностей.
+// Совпавшие окна не хранятся, а находятся заново при каждом выводе, так что память не зависит от их числа.
+type DotPlot struct {
+	Len1 int
+	Len2 int
+	Word int
+	// Path клетки пути выравнивания или nil
+	Path []Dot
+
+	cfg    DotPlotConfig
+	runes1 []rune
+	runes2 []rune
+}
+
+// NewDotPlot сравнивает все окна длины cfg.Word последовательностей seq1 и seq2
+func NewDotPlot(seq1, seq2 string, cfg *DotPlotConfig) (*DotPlot, error) {
+	if cfg.Word <= 0 {
+		return nil, ErrBadWordSize
+	}
+	if cfg.Mismatches < 0 {
+		return nil, ErrNegativeMismatches
+	}
+
+	runes1, runes2 := []rune(seq1), []rune(seq2)
+	return &DotPlot{Len1: len(runes1), Len2: len(runes2), Word: cfg.Word, cfg: *cfg, runes1: runes1, runes2: runes2}, nil
+}
+
+// Runs вызывает fn для каждого ряда совпавших подряд окон прямой и обратной цепи.
+// Ряд прямой цепи занимает клетки (i+k, j+k), обратной — (i+k, j-k) для k < count+Word-1:
+// j обратной цепи — последний символ первого окна во второй последовательности, окна идут к её началу.
+func (p *DotPlot) Runs(fn func(i, j, count int, reverse bool)) {
+	if p.cfg.Forward {
+		p.strandRuns(false, func(i, j, count int) { fn(i, j, count, false) })
+	}
+	if p.cfg.Reverse {
+		p.strandRuns(true, func(i, j, count int) { fn(i, j, count, true) })
+	}
+}
+
+// strandRuns вызывает fn для каждого ряда совпавших подряд окон одной цепи
+func (p *DotPlot) strandRuns(reverse bool, fn runFunc) {
+	if !reverse {
+		matchWindows(p.runes1, p.runes2, p.Word, p.cfg.Mismatches, fn)
+		return
+	}
+	complement := []rune(ReverseComplement(string(p.runes2)))
+	// окно обратной цепи, начинающееся в j, заканчивается во второй последовательности в len2-1-j
+	matchWindows(p.runes1, complement, p.Word, p.cfg.Mismatches, func(i, j, count int) {
+		fn(i, p.Len2-1-j, count)
+	})
+}
+
+// matchWindows передаёт в fn ряды окон длины word, в которых a и b различаются не более чем в mismatches символах
+func matchWindows(a, b []rune, word, mismatches int, fn runFunc) {
+	if len(a) < word || len(b) < word {
+		return
+	}
+	if mismatches == 0 {
+		matchWords(a, b, word, fn)
+		return
+	}
+
+	// окна сравниваются вдоль каждой диагонали j-i со скользящим подсчётом несовпадений
+	for d := -(len(a) - word); d <= len(b)-word; d++ {
+		i := MaxInt(0, -d)
+		diff := 0
+		for k := 0; k < word; k++ {
+			if a[i+k] != b[i+d+k] {
+				diff++
+			}
+		}
+		// start начало текущего ряда или -1
+		start := -1
+		for {
+			if diff <= mismatches {
+				if start < 0 {
+					start = i
+				}
+			} else if start >= 0 {
+				fn(start, start+d, i-start)
+				start = -1
+			}
+			if i+word >= len(a) || i+d+word >= len(b) {
+				break
+			}
+			if a[i] != b[i+d] {
+				diff--
+			}
+			if a[i+word] != b[i+d+word] {
+				diff++
+			}
+			i++
+		}
+		if start >= 0 {
+			fn(start, start+d, i+1-start)
+		}
+	}
+}
+
+// matchWords передаёт в fn ряды совпадающих слов длины word, найденные по таблице слов первой последовательности
+func matchWords(a, b []rune, word int, fn runFunc) {
+	words := make(map[string][]int)
+	for i := 0; i+word <= len(a); i++ {
+		key := string(a[i : i+word])
+		words[key] = append(words[key], i)
+	}
+
+	// открытый ряд диагонали j-i+len(a) начинается в start и заканчивается окном end-1 второй последовательности,
+	// end равен 0, если ряда нет
+	start := make([]int, len(a)+len(b))
+	end := make([]int, len(a)+len(b))
+	flush := func(d int) {
+		if end[d] > 0 {
+			i := start[d]
+			j := i + d - len(a)
+			fn(i, j, end[d]-j)
+		}
+	}
+	for j := 0; j+word <= len(b); j++ {
+		for _, i := range words[string(b[j:j+word])] {
+			d := j - i + len(a)
+			// ряд продолжается, только если на этой диагонали совпало предыдущее окно
+			if end[d] == 0 || end[d] != j {
+				flush(d)
+				start[d] = i
+			}
+			end[d] = j + 1
+		}
+	}
+	for d := range end {
+		flush(d)
+	}
+}
+
+// SetPath добавляет на диаграмму путь выравнивания res
+func (p *DotPlot) SetPath(res *AlignResult) {
+	runes1, runes2 := []rune(res.Aligned1), []rune(res.Aligned2)
+	p.Path = make([]Dot, 0, len(runes1))
+	if p.Len1 == 0 || p.Len2 == 0 {
+		return
+	}
+	i, j := res.Start1, res.Start2
+	for k := range runes1 {
+		p.Path = append(p.Path, Dot{I: MinInt(i, p.Len1-1), J: MinInt(j, p.Len2-1)})
+		if runes1[k] != gapRune {
+			i++
+		}
+		if runes2[k] != gapRune {
+			j++
+		}
+	}
+}
+
+// forEachCell вызывает fn для каждой клетки совпавших окон прямой и обратной цепи
+func (p *DotPlot) forEachCell(fn func(i, j int, reverse bool)) {
+	p.Runs(func(i, j, count int, reverse bool) {
+		dir := runDirection(reverse)
+		for k := 0; k < count+p.Word-1; k++ {
+			fn(i+k, j+dir*k, reverse)
+		}
+	})
+}
+
+// runDirection возвращает шаг по второй последовательности вдоль ряда окон
+func runDirection(reverse bool) int {
+	if reverse {
+		return -1
+	}
+	return 1
+}
+
+// WriteText выводит диаграмму символами: первая последовательность seq1 по горизонтали, вторая seq2 по вертикали.
+// '\' означает совпадение прямой цепи, '/' — обратной, 'X' — обеих, '*' — путь выравнивания.
+func (p *DotPlot) WriteText(w io.Writer, seq1, seq2 string) error {
+	if MaxInt(p.Len1, p.Len2) > maxTextDotPlot {
+		return errors.Wrapf(ErrDotPlotTooLarge, "limit is %d", maxTextDotPlot)
+	}
+
+	grid := make([][]rune, p.Len2)
+	for j := range grid {
+		grid[j] = []rune(strings.Repeat(string(textPlotEmpty), p.Len1))
+	}
+	p.forEachCell(func(i, j int, reverse bool) {
+		symbol := textPlotForward
+		if reverse {
+			symbol = textPlotReverse
+		}
+		if cell := grid[j][i]; cell != textPlotEmpty && cell != symbol {
+			symbol = textPlotBoth
+		}
+		grid[j][i] = symbol
+	})
+	for _, d := range p.Path {
+		grid[d.J][d.I] = textPlotPath
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "  %s\n", seq1)
+	runes2 := []rune(seq2)
+	for j, row := range grid {
+		fmt.Fprintf(bw, "%c %s\n", runes2[j], string(row))
+	}
+	return bw.Flush()
+}
+
+// plotRaster отображение клеток диаграммы в пиксели изображения не больше size точек по каждой стороне
+type plotRaster struct {
+	// cellsPerPixel число клеток в пикселе, pixelsPerCell размер клетки в пикселях
+	cellsPerPixel int
+	pixelsPerCell int
+	width         int
+	height        int
+}
+
+func newPlotRaster(len1, len2, size int) *plotRaster {
+	longest := MaxInt(MaxInt(len1, len2), 1)
+	r := &plotRaster{cellsPerPixel: 1, pixelsPerCell: 1}
+	if longest > size {
+		r.cellsPerPixel = (longest + size - 1) / size
+	} else {
+		r.pixelsPerCell = size / longest
+	}
+	r.width = MaxInt((len1+r.cellsPerPixel-1)/r.cellsPerPixel*r.pixelsPerCell, 1)
+	r.height = MaxInt((len2+r.cellsPerPixel-1)/r.cellsPerPixel*r.pixelsPerCell, 1)
+	return r
+}
+
+// segment вызывает fill один раз для каждой клетки изображения (в клетках, а не пикселях),
+// через которую проходят length клеток диаграммы от (i, j) с шагом dir по второй последовательности
+func (r *plotRaster) segment(i, j, length, dir int, fill func(x, y int)) {
+	cells := r.cellsPerPixel
+	for k := 0; k < length; {
+		ci, cj := i+k, j+dir*k
+		fill(ci/cells, cj/cells)
+		// переход сразу к клетке, которая попадает в следующий пиксель
+		step := cells - ci%cells
+		if dir > 0 {
+			step = MinInt(step, cells-cj%cells)
+		} else {
+			step = MinInt(step, cj%cells+1)
+		}
+		k += step
+	}
+}
+
+// Цвета изображений точечной диаграммы
+var (
+	plotBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	plotForward    = color.RGBA{0x00, 0x00, 0x00, 0xff}
+	plotReverse    = color.RGBA{0xcf, 0x22, 0x2e, 0xff}
+	plotPath       = color.RGBA{0x05, 0x50, 0xae, 0xff}
+)
+
+// WritePNG выводит диаграмму изображением PNG не больше size пикселей по каждой стороне.
+// Если клеток больше, чем пикселей, то пиксель закрашивается при совпадении хотя бы в одной его клетке.
+func (p *DotPlot) WritePNG(w io.Writer, size int) error {
+	r := newPlotRaster(p.Len1, p.Len2, size)
+	img := image.NewPaletted(image.Rect(0, 0, r.width, r.height),
+		color.Palette{plotBackground, plotForward, plotReverse, plotPath})
+
+	fill := func(index uint8) func(x, y int) {
+		return func(x, y int) {
+			x, y = x*r.pixelsPerCell, y*r.pixelsPerCell
+			for dy := 0; dy < r.pixelsPerCell; dy++ {
+				for dx := 0; dx < r.pixelsPerCell; dx++ {
+					img.SetColorIndex(x+dx, y+dy, index)
+				}
+			}
+		}
+	}
+	forward, reverse := fill(1), fill(2)
+	p.Runs(func(i, j, count int, isReverse bool) {
+		if isReverse {
+			r.segment(i, j, count+p.Word-1, -1, reverse)
+		} else {
+			r.segment(i, j, count+p.Word-1, 1, forward)
+		}
+	})
+	path := fill(3)
+	for _, d := range p.Path {
+		r.segment(d.I, d.J, 1, 1, path)
+	}
+	return png.Encode(w, img)
+}
+
+// WriteSVG выводит диаграмму изображением SVG не больше size точек по каждой стороне.
+// Ряды совпавших окон рисуются отрезками, путь выравнивания — ломаной.
+// Если рядов больше maxSVGRuns, то возвращается ErrTooManyMatches.
+func (p *DotPlot) WriteSVG(w io.Writer, size int, name1, name2 string) error {
+	runs := 0
+	p.Runs(func(i, j, count int, reverse bool) { runs++ })
+	if runs > maxSVGRuns {
+		return errors.Wrapf(ErrTooManyMatches, "%d segments, limit is %d", runs, maxSVGRuns)
+	}
+
+	longest := MaxInt(MaxInt(p.Len1, p.Len2), 1)
+	width, height := MaxInt(p.Len1*size/longest, 1), MaxInt(p.Len2*size/longest, 1)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		width, height, MaxInt(p.Len1, 1), MaxInt(p.Len2, 1))
+	fmt.Fprintf(bw, "<title>%s (x) vs %s (y), word %d</title>\n", xmlEscape(name1), xmlEscape(name2), p.Word)
+	fmt.Fprintf(bw, `<rect width="100%%" height="100%%" fill="#fff"/>`+"\n")
+
+	writeLines := func(reverse bool, stroke string) {
+		fmt.Fprintf(bw, `<g stroke="%s" stroke-width="1" vector-effect="non-scaling-stroke">`+"\n", stroke)
+		dir := runDirection(reverse)
+		p.strandRuns(reverse, func(i, j, count int) {
+			// отрезок проходит через клетки ряда окон от их центров
+			x1, y1 := float64(i)+0.5, float64(j)+0.5
+			x2, y2 := x1+float64(count+p.Word-2), y1+float64(dir*(count+p.Word-2))
+			fmt.Fprintf(bw, `<line x1="%g" y1="%g" x2="%g" y2="%g" vector-effect="non-scaling-stroke"/>`+"\n", x1, y1, x2, y2)
+		})
+		fmt.Fprintf(bw, "</g>\n")
+	}
+	if p.cfg.Forward {
+		writeLines(false, "#000")
+	}
+	if p.cfg.Reverse {
+		writeLines(true, "#cf222e")
+	}
+
+	if len(p.Path) > 0 {
+		points := make([]string, len(p.Path))
+		for k, d := range p.Path {
+			points[k] = fmt.Sprintf("%g,%g", float64(d.I)+0.5, float64(d.J)+0.5)
+		}
+		fmt.Fprintf(bw, `<polyline fill="none" stroke="#0550ae" stroke-width="2" vector-effect="non-scaling-stroke" points="%s"/>`+"\n",
+			strings.Join(points, " "))
+	}
+	fmt.Fprintf(bw, "</svg>\n")
+	return bw.Flush()
+}
+
+// xmlEscape экранирует специальные символы XML в тексте
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
+
+// runDotPlot строит точечную диаграмму записей, выбранных как для выравнивания
+func runDotPlot(args []string) error {
+	fs := flag.NewFlagSet("dotplot", flag.ContinueOnError)
+	word := fs.Int("word", 10, "word size")
+	mismatches := fs.Int("mismatches", 0, "maximal number of mismatches in a word")
+	strand := fs.String("strand", strandForward, "(forward|reverse|both) strands of the second sequence")
+	format := fs.String("format", plotTextFormat, "(text|png|svg) output format")
+	size := fs.Int("size", defaultPlotSize, "maximal png or svg image side in pixels")
+	path := fs.Bool("path", false, "overlays the optimal alignment path")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := &DotPlotConfig{Word: *word, Mismatches: *mismatches}
+	switch *strand {
+	case strandForward:
+		cfg.Forward = true
+	case strandReverse:
+		cfg.Reverse = true
+	case strandBoth:
+		cfg.Forward, cfg.Reverse = true, true
+	default:
+		return errors.Wrap(ErrUnknownStrand, *strand)
+	}
+	switch *format {
+	case plotTextFormat, plotPNGFormat, plotSVGFormat:
+	default:
+		return errors.Wrap(ErrUnknownPlotFormat, *format)
+	}
+	if *size <= 0 {
+		return errors.Errorf("image size must be positive, got %d", *size)
+	}
+
+	selectCfg, err := buildSelectConfig()
+	if err != nil {
+		return errors.Wrap(err, "can not parse --select")
+	}
+	sequences, err := loadSequences(fs.Args(), selectCfg, buildReaderConfig())
+	if err != nil {
+		return err
+	}
+	seq1, seq2 := sequences[0], sequences[1]
+
+	plot, err := NewDotPlot(seq1.Value, seq2.Value, cfg)
+	if err != nil {
+		return err
+	}
+	if *path {
+		adapter, aligner, _, err := buildScoring()
+		if err != nil {
+			return errors.Wrap(err, "can not set up scoring")
+		}
+		if err := validate(adapter, sequences); err != nil {
+			return err
+		}
+		res, err := aligner.AlignDetailed(seq1.Value, seq2.Value, nil, nil)
+		if err != nil {
+			return err
+		}
+		plot.SetPath(res)
+	}
+
+	out := os.Stdout
+	if outputFile != "" {
+		if out, err = os.Create(outputFile); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	switch *format {
+	case plotPNGFormat:
+		return plot.WritePNG(out, *size)
+	case plotSVGFormat:
+		return plot.WriteSVG(out, *size, recordName(seq1, "seq1"), recordName(seq2, "seq2"))
+	}
+	return plot.WriteText(out, seq1.Value, seq2.Value)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type DotPlotTestSuite struct {
+	suite.Suite
+}
+
+func (s *DotPlotTestSuite) TestReverseComplement() {
+	s.Equal("ACGTN", ReverseComplement("NACGT"))
+	s.Equal("tgcaRY", ReverseComplement("RYtgca"))
+	s.Equal("", ReverseComplement(""))
+}
+
+// bruteWindows сравнивает окна всех пар позиций
+func bruteWindows(a, b string, word, mismatches int) []Dot {
+	var dots []Dot
+	for i := 0; i+word <= len(a); i++ {
+		for j := 0; j+word <= len(b); j++ {
+			diff := 0
+			for k := 0; k < word; k++ {
+				if a[i+k] != b[j+k] {
+					diff++
+				}
+			}
+			if diff <= mismatches {
+				dots = append(dots, Dot{I: i, J: j})
+			}
+		}
+	}
+	return dots
+}
+
+func sortDots(dots []Dot) []Dot {
+	sort.Slice(dots, func(x, y int) bool {
+		if dots[x].I != dots[y].I {
+			return dots[x].I < dots[y].I
+		}
+		return dots[x].J < dots[y].J
+	})
+	return dots
+}
+
+// windowDots возвращает начала всех окон рядов, найденных matchWindows, и число рядов
+func windowDots(a, b string, word, mismatches int) ([]Dot, int) {
+	var dots []Dot
+	runs := 0
+	matchWindows([]rune(a), []rune(b), word, mismatches, func(i, j, count int) {
+		runs++
+		for k := 0; k < count; k++ {
+			dots = append(dots, Dot{I: i + k, J: j + k})
+		}
+	})
+	return sortDots(dots), runs
+}
+
+// plotDots возвращает начала окон прямой и обратной цепи диаграммы
+func plotDots(plot *DotPlot) ([]Dot, []Dot) {
+	var forward, reverse []Dot
+	plot.Runs(func(i, j, count int, isReverse bool) {
+		for k := 0; k < count; k++ {
+			if isReverse {
+				reverse = append(reverse, Dot{I: i + k, J: j - k})
+			} else {
+				forward = append(forward, Dot{I: i + k, J: j + k})
+			}
+		}
+	})
+	return sortDots(forward), sortDots(reverse)
+}
+
+func (s *DotPlotTestSuite) TestMatchWindows() {
+	rnd := rand.New(rand.NewSource(1))
+	random := func() string {
+		b := make([]byte, rnd.Intn(30))
+		for i := range b {
+			b[i] = "ACGT"[rnd.Intn(4)]
+		}
+		return string(b)
+	}
+	for k := 0; k < 100; k++ {
+		a, b := random(), random()
+		for _, mismatches := range []int{0, 1, 2} {
+			exp := sortDots(bruteWindows(a, b, 4, mismatches))
+			dots, runs := windowDots(a, b, 4, mismatches)
+			s.Equal(exp, dots, "%s %s %d", a, b, mismatches)
+
+			// ряды не продолжают друг друга: ряд начинается в каждом окне без совпавшего окна перед ним
+			starts := 0
+			found := make(map[Dot]bool)
+			for _, d := range exp {
+				found[d] = true
+			}
+			for _, d := range exp {
+				if !found[Dot{I: d.I - 1, J: d.J - 1}] {
+					starts++
+				}
+			}
+			s.Equal(starts, runs, "%s %s %d", a, b, mismatches)
+		}
+	}
+}
+
+func (s *DotPlotTestSuite) TestNewDotPlot() {
+	plot, err := NewDotPlot("ACGTAA", "TTACGA", &DotPlotConfig{Word: 3, Forward: true, Reverse: true})
+	s.Require().NoError(err)
+	forward, reverse := plotDots(plot)
+	s.Equal([]Dot{{I: 0, J: 2}}, forward)
+	// CGT в первой последовательности комплементарно ACG на позициях 2-4 второй, окно идёт от 4 к 2
+	s.Equal([]Dot{{I: 1, J: 4}, {I: 2, J: 3}, {I: 3, J: 2}}, reverse)
+
+	_, err = NewDotPlot("A", "A", &DotPlotConfig{Word: 0, Forward: true})
+	s.Equal(ErrBadWordSize, err)
+	_, err = NewDotPlot("A", "A", &DotPlotConfig{Word: 1, Mismatches: -1, Forward: true})
+	s.Equal(ErrNegativeMismatches, err)
+}
+
+func (s *DotPlotTestSuite) TestWriteText() {
+	plot, err := NewDotPlot("ACGT", "ACGA", &DotPlotConfig{Word: 2, Forward: true, Reverse: true})
+	s.Require().NoError(err)
+
+	var buf bytes.Buffer
+	s.Require().NoError(plot.WriteText(&buf, "ACGT", "ACGA"))
+	s.Equal(""+
+		"  ACGT\n"+
+		"A \\../\n"+
+		"C .\\/.\n"+
+		"G ./\\.\n"+
+		"A ....\n", buf.String())
+
+	plot.SetPath(&AlignResult{Aligned1: "ACGT", Aligned2: "ACGA", End1: 4, End2: 4, Len1: 4, Len2: 4})
+	buf.Reset()
+	s.Require().NoError(plot.WriteText(&buf, "ACGT", "ACGA"))
+	s.Equal(""+
+		"  ACGT\n"+
+		"A *../\n"+
+		"C .*/.\n"+
+		"G ./*.\n"+
+		"A ...*\n", buf.String())
+
+	long := strings.Repeat("A", maxTextDotPlot+1)
+	plot, err = NewDotPlot(long, "A", &DotPlotConfig{Word: 1, Forward: true})
+	s.Require().NoError(err)
+	s.Equal(ErrDotPlotTooLarge, errors.Cause(plot.WriteText(&buf, long, "A")))
+}
+
+func (s *DotPlotTestSuite) TestWriteImages() {
+	seq := strings.Repeat("ACGTTGCA", 50)
+	plot, err := NewDotPlot(seq, seq[:200], &DotPlotConfig{Word: 4, Forward: true})
+	s.Require().NoError(err)
+
+	var buf bytes.Buffer
+	s.Require().NoError(plot.WritePNG(&buf, 100))
+	img, err := png.Decode(&buf)
+	s.Require().NoError(err)
+	s.Equal(100, img.Bounds().Dx())
+	s.Equal(50, img.Bounds().Dy())
+
+	buf.Reset()
+	s.Require().NoError(plot.WriteSVG(&buf, 100, "a<b", "c"))
+	s.Contains(buf.String(), `width="100" height="50" viewBox="0 0 400 200"`)
+	s.Contains(buf.String(), "<title>a&lt;b (x) vs c (y), word 4</title>")
+	// совпадения главной диагонали сливаются в один отрезок
+	s.Contains(buf.String(), `<line x1="0.5" y1="0.5" x2="199.5" y2="199.5"`)
+	s.Equal(1, strings.Count(buf.String(), `<line x1="0.5" y1="0.5"`))
+}
+
+func (s *DotPlotTestSuite) TestRasterSegment() {
+	r := newPlotRaster(100, 100, 30)
+	s.Require().Equal(4, r.cellsPerPixel)
+	for _, c := range []struct{ i, j, length, dir int }{
+		{0, 0, 100, 1}, {3, 1, 10, 1}, {5, 2, 1, 1}, {0, 99, 100, -1}, {7, 50, 13, -1}, {2, 8, 9, -1},
+	} {
+		exp := make(map[Dot]bool)
+		for k := 0; k < c.length; k++ {
+			exp[Dot{I: (c.i + k) / 4, J: (c.j + c.dir*k) / 4}] = true
+		}
+		got := make(map[Dot]bool)
+		r.segment(c.i, c.j, c.length, c.dir, func(x, y int) {
+			s.False(got[Dot{I: x, J: y}], "%v", c)
+			got[Dot{I: x, J: y}] = true
+		})
+		s.Equal(exp, got, "%v", c)
+	}
+}
+
+func (s *DotPlotTestSuite) TestLowComplexity() {
+	// каждая пара окон совпадает, но на каждой диагонали остаётся один ряд
+	seq := strings.Repeat("A", 3000)
+	plot, err := NewDotPlot(seq, seq, &DotPlotConfig{Word: 10, Forward: true})
+	s.Require().NoError(err)
+
+	runs := 0
+	plot.Runs(func(i, j, count int, reverse bool) { runs++ })
+	s.Equal(2*(len(seq)-10)+1, runs)
+
+	var buf bytes.Buffer
+	s.Require().NoError(plot.WritePNG(&buf, 100))
+	img, err := png.Decode(&buf)
+	s.Require().NoError(err)
+	s.Equal(100, img.Bounds().Dx())
+
+	plot, err = NewDotPlot(seq, seq[:1000]+strings.Repeat("AC", 1000), &DotPlotConfig{Word: 1, Forward: true})
+	s.Require().NoError(err)
+	s.Equal(ErrTooManyMatches, errors.Cause(plot.WriteSVG(&buf, 100, "a", "b")))
+}
+
+func TestDotPlotSuite(t *testing.T) {
+	suite.Run(t, new(DotPlotTestSuite))
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// embossLineLength число символов выравнивания в строке отчёта EMBOSS
+	embossLineLength = 50
+	// embossNameWidth ширина колонки имени последовательности
+	embossNameWidth = 13
+)
+
+// Символы строки разметки выравнивания в стиле EMBOSS
+const (
+	markupIdentity = '|'
+	markupSimilar  = ':'
+	markupMismatch = '.'
+	markupGap      = ' '
+)
+
+// markupSymbol возвращает символ разметки для пары выровненных символов.
+// Похожими считаются различные символы с положительной оценкой scorer, nil scorer не находит похожих.
+func markupSymbol(scorer Scorer, a, b rune) rune {
+	switch {
+	case a == gapRune || b == gapRune:
+		return markupGap
+	case a == b:
+		return markupIdentity
+	case scorer != nil && scoreRunes(scorer, a, b) > 0:
+		return markupSimilar
+	}
+	return markupMismatch
+}
+
+// embossFormatter выводит выравнивания в формате pair программ needle и water пакета EMBOSS
+type embossFormatter struct {
+	cfg *FormatConfig
+}
+
+func (f *embossFormatter) Format(w io.Writer, records []*AlignmentRecord) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "########################################\n")
+	fmt.Fprintf(bw, "# Program: %s\n", programName)
+	fmt.Fprintf(bw, "# Align_format: pair\n")
+	fmt.Fprintf(bw, "########################################\n\n")
+
+	for _, rec := range records {
+		f.writeRecord(bw, rec)
+	}
+
+	fmt.Fprintf(bw, "#---------------------------------------\n")
+	fmt.Fprintf(bw, "#---------------------------------------\n")
+	return bw.Flush()
+}
+
+func (f *embossFormatter) writeRecord(w io.Writer, rec *AlignmentRecord) {
+	runes1, runes2 := []rune(rec.Aligned1), []rune(rec.Aligned2)
+	markup := make([]rune, len(runes1))
+	for i := range runes1 {
+		markup[i] = markupSymbol(f.cfg.Scorer, runes1[i], runes2[i])
+	}
+	stats := ComputeStats(rec.AlignResult, f.cfg.Scorer)
+
+	name1, name2 := recordName(rec.Seq1, "seq1"), recordName(rec.Seq2, "seq2")
+	fmt.Fprintf(w, "#=======================================\n#\n")
+	fmt.Fprintf(w, "# Aligned_sequences: 2\n")
+	fmt.Fprintf(w, "# 1: %s\n", name1)
+	fmt.Fprintf(w, "# 2: %s\n", name2)
+	if scoring := f.cfg.Scoring; scoring != nil {
+		fmt.Fprintf(w, "# Matrix: %s\n", scoring.Matrix)
+		// в EMBOSS штрафы записываются положительными числами
+		fmt.Fprintf(w, "# Gap_penalty: %.1f\n", float64(-scoring.GapOpen))
+		fmt.Fprintf(w, "# Extend_penalty: %.1f\n", float64(-scoring.GapExtend))
+	}
+	fmt.Fprintf(w, "#\n")
+	fmt.Fprintf(w, "# Length: %d\n", stats.Length)
+	fmt.Fprintf(w, "# Identity:   %11s (%s)\n", fmt.Sprintf("%d/%d", stats.Identity, stats.Length), percent(stats.Identity, stats.Length))
+	fmt.Fprintf(w, "# Similarity: %11s (%s)\n", fmt.Sprintf("%d/%d", stats.Similarity, stats.Length), percent(stats.Similarity, stats.Length))
+	fmt.Fprintf(w, "# Gaps:       %11s (%s)\n", fmt.Sprintf("%d/%d", stats.Gaps, stats.Length), percent(stats.Gaps, stats.Length))
+	fmt.Fprintf(w, "# Score: %.1f\n", float64(rec.Score))
+	fmt.Fprintf(w, "#\n#\n#=======================================\n\n")
+
+	pos1, pos2 := rec.Start1, rec.Start2
+	for l := 0; l < len(runes1); l += embossLineLength {
+		r := MinInt(len(runes1), l+embossLineLength)
+		pos1 = writeEmbossLine(w, name1, pos1, runes1[l:r])
+		fmt.Fprintf(w, "%s%s\n", strings.Repeat(" ", embossNameWidth+8), string(markup[l:r]))
+		pos2 = writeEmbossLine(w, name2, pos2, runes2[l:r])
+		fmt.Fprintf(w, "\n")
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+// writeEmbossLine выводит часть выровненной последовательности с номерами первого и последнего её символа (с 1).
+// pos число символов последовательности перед этой частью, возвращается число символов после неё.
+func writeEmbossLine(w io.Writer, name string, pos int, part []rune) int {
+	start := pos
+	for _, r := range part {
+		if r != gapRune {
+			pos++
+		}
+	}
+	// в строке из одних gap EMBOSS повторяет номер последнего символа
+	if pos > start {
+		start++
+	}
+	fmt.Fprintf(w, "%-*.*s %6d %s %6d\n", embossNameWidth, embossNameWidth, name, start, string(part), pos)
+	return pos
+}
+
+// percent возвращает долю part от total в процентах с одним знаком после запятой
+func percent(part, total int) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(part)/float64(total))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type EmbossTestSuite struct {
+	suite.Suite
+}
+
+func (s *EmbossTestSuite) TestMarkupSymbol() {
+	scorer := NewProteinAdapterBLOSUM62()
+	s.Equal(markupIdentity, markupSymbol(scorer, 'W', 'W'))
+	s.Equal(markupSimilar, markupSymbol(scorer, 'I', 'V'))
+	s.Equal(markupMismatch, markupSymbol(scorer, 'W', 'A'))
+	s.Equal(markupGap, markupSymbol(scorer, '-', 'A'))
+	s.Equal(markupMismatch, markupSymbol(nil, 'I', 'V'))
+}
+
+func (s *EmbossTestSuite) TestFormat() {
+	scorer := NewProteinAdapterBLOSUM62()
+	seq1 := &Sequence{Description: "p1 first protein", Value: "HEAGAWGHEI"}
+	seq2 := &Sequence{Description: "p2", Value: "PAWHEV"}
+	aligner := NewSequenceAligner(&SequenceAlignerConfig{GapPenalty: -8, AllowLocal: true}, scorer)
+
+	var buf bytes.Buffer
+	err := (&embossFormatter{cfg: &FormatConfig{
+		Scorer:  scorer,
+		Scoring: &ScoringInfo{Matrix: "BLOSUM62", GapOpen: -8, GapExtend: -8},
+	}}).Format(&buf, []*AlignmentRecord{{
+		Seq1:        seq1,
+		Seq2:        seq2,
+		AlignResult: alignDetailed(aligner, seq1.Value, seq2.Value),
+	}})
+	s.Require().NoError(err)
+	s.Contains(buf.String(), "# 1: p1\n# 2: p2\n# Matrix: BLOSUM62\n# Gap_penalty: 8.0\n# Extend_penalty: 8.0\n")
+	s.Contains(buf.String(), "# Length: 6\n"+
+		"# Identity:           4/6 (66.7%)\n"+
+		"# Similarity:         5/6 (83.3%)\n"+
+		"# Gaps:               1/6 (16.7%)\n"+
+		"# Score: 23.0\n")
+	s.Contains(buf.String(), ""+
+		"p1                 5 AWGHEI     10\n"+
+		"                     || ||:\n"+
+		"p2                 2 AW-HEV      6\n")
+}
+
+func TestEmbossSuite(t *testing.T) {
+	suite.Run(t, new(EmbossTestSuite))
+}
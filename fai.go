@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Possible FASTA index errors
+var (
+	ErrBadIndexLine    = errors.New("fasta index: bad line")
+	ErrUnevenLines     = errors.New("fasta index: lines of a record have different length")
+	ErrIndexNoRecord   = errors.New("fasta index: no such record")
+	ErrIndexNotFasta   = errors.New("fasta index: input is not fasta")
+	ErrRegionOutOfSeq  = errors.New("region is out of sequence")
+	ErrBadRegionBounds = errors.New("bad region bounds")
+)
+
+// faiSuffix is appended to FASTA file name to get its index file name
+const faiSuffix = ".fai"
+
+// FaiEntry is a line of samtools-compatible FASTA index
+type FaiEntry struct {
+	Name string
+	// Length is the number of bases in the record
+	Length int64
+	// Offset is the byte offset of the first base
+	Offset int64
+	// LineBases is the number of bases per line
+	LineBases int64
+	// LineWidth is the number of bytes per line including line ending
+	LineWidth int64
+}
+
+// FastaIndex is a samtools-compatible FASTA index (.fai)
+type FastaIndex struct {
+	Entries []FaiEntry
+	byName  map[string]int
+}
+
+func newFastaIndex(entries []FaiEntry) *FastaIndex {
+	idx := &FastaIndex{
+		Entries: entries,
+		byName:  make(map[string]int, len(entries)),
+	}
+	for i, e := range entries {
+		idx.byName[e.Name] = i
+	}
+	return idx
+}
+
+// BuildFastaIndex indexes FASTA content of r.
+// All lines of a record except the last one must have the same length.
+func BuildFastaIndex(r io.Reader) (*FastaIndex, error) {
+	reader := bufio.NewReader(r)
+	var entries []FaiEntry
+	var current *FaiEntry
+	// lastLine is true after a line shorter than LineBases or an empty line,
+	// only the end of record may follow it
+	lastLine := false
+	// ending is the line ending length of earlier lines, LF unless CRLF is met
+	ending := int64(1)
+	var offset int64
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// a long line, read it whole
+			head := append([]byte{}, line...)
+			rest, restErr := reader.ReadBytes('\n')
+			line, err = append(head, rest...), restErr
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(line) == 0 {
+			break
+		}
+		width := int64(len(line))
+		offset += width
+		text := bytes.TrimRight(line, "\r\n")
+		bases := int64(len(text))
+		if err != io.EOF {
+			ending = width - bases
+		}
+
+		switch {
+		case len(text) > 0 && text[0] == '>':
+			fields := strings.Fields(string(text[1:]))
+			name := ""
+			if len(fields) > 0 {
+				name = fields[0]
+			}
+			entries = append(entries, FaiEntry{Name: name, Offset: offset})
+			current = &entries[len(entries)-1]
+			lastLine = false
+		case current == nil:
+			if bases > 0 {
+				return nil, errors.Wrapf(ErrIndexNotFasta, "line %d", lineNumber)
+			}
+		case bases == 0:
+			lastLine = true
+		case lastLine:
+			return nil, errors.Wrapf(ErrUnevenLines, "record %s, line %d", current.Name, lineNumber)
+		default:
+			// the last line of file may miss its line ending, it is taken from earlier lines
+			if err == io.EOF {
+				width = bases + ending
+			}
+			if current.LineBases == 0 {
+				current.LineBases, current.LineWidth = bases, width
+			} else if bases > current.LineBases || err != io.EOF && width-bases != current.LineWidth-current.LineBases {
+				return nil, errors.Wrapf(ErrUnevenLines, "record %s, line %d", current.Name, lineNumber)
+			}
+			lastLine = bases < current.LineBases
+			current.Length += bases
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	return newFastaIndex(entries), nil
+}
+
+// ReadFastaIndex reads .fai content of r
+func ReadFastaIndex(r io.Reader) (*FastaIndex, error) {
+	var entries []FaiEntry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 5 {
+			return nil, errors.Wrapf(ErrBadIndexLine, "line %d", line)
+		}
+
+		var numbers [4]int64
+		for i := range numbers {
+			n, err := strconv.ParseInt(fields[i+1], 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(ErrBadIndexLine, "line %d: %s", line, err)
+			}
+			numbers[i] = n
+		}
+		entries = append(entries, FaiEntry{
+			Name:      fields[0],
+			Length:    numbers[0],
+			Offset:    numbers[1],
+			LineBases: numbers[2],
+			LineWidth: numbers[3],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return newFastaIndex(entries), nil
+}
+
+// Write writes index in .fai format
+func (idx *FastaIndex) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range idx.Entries {
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%d\t%d\n", e.Name, e.Length, e.Offset, e.LineBases, e.LineWidth)
+	}
+	return bw.Flush()
+}
+
+// Lookup returns entry by record name or by "#N" 1-based record number
+func (idx *FastaIndex) Lookup(name string) (*FaiEntry, error) {
+	if strings.HasPrefix(name, recordIndexPrefix) {
+		n, err := strconv.Atoi(name[len(recordIndexPrefix):])
+		if err != nil || n < 1 || n > len(idx.Entries) {
+			return nil, errors.Wrap(ErrIndexNoRecord, name)
+		}
+		return &idx.Entries[n-1], nil
+	}
+
+	i, ok := idx.byName[name]
+	if !ok {
+		return nil, errors.Wrap(ErrIndexNoRecord, name)
+	}
+	return &idx.Entries[i], nil
+}
+
+// Fetch reads bases [start, end) (0-based) of the record e from indexed FASTA r
+func (e *FaiEntry) Fetch(r io.ReaderAt, start, end int64) (string, error) {
+	if start < 0 || end > e.Length || start > end {
+		return "", errors.Wrapf(ErrRegionOutOfSeq, "%s has %d bases", e.Name, e.Length)
+	}
+	if start == end {
+		return "", nil
+	}
+
+	from, to := e.position(start), e.position(end-1)+1
+	raw := make([]byte, to-from)
+	if _, err := r.ReadAt(raw, from); err != nil && err != io.EOF {
+		return "", err
+	}
+
+	res := raw[:0]
+	for _, b := range raw {
+		if b != '\n' && b != '\r' {
+			res = append(res, b)
+		}
+	}
+	return string(res), nil
+}
+
+// position returns byte offset of 0-based base pos
+func (e *FaiEntry) position(pos int64) int64 {
+	if e.LineBases == 0 {
+		return e.Offset
+	}
+	return e.Offset + pos/e.LineBases*e.LineWidth + pos%e.LineBases
+}
+
+// Region is a 1-based inclusive interval of a sequence, End == 0 means the end of sequence
+type Region struct {
+	Start int64
+	End   int64
+}
+
+// ParseRegion splits selector like "chr1:1000-5000" or "chr1:1,000" into the record selector and region.
+// Returns false if there is no region suffix.
+func ParseRegion(selector string) (string, Region, bool) {
+	i := strings.LastIndexByte(selector, ':')
+	if i < 0 {
+		return selector, Region{}, false
+	}
+
+	bounds := strings.ReplaceAll(selector[i+1:], ",", "")
+	startStr, endStr := bounds, ""
+	if j := strings.IndexByte(bounds, '-'); j >= 0 {
+		startStr, endStr = bounds[:j], bounds[j+1:]
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil {
+		return selector, Region{}, false
+	}
+	region := Region{Start: start}
+	if endStr != "" {
+		if region.End, err = strconv.ParseInt(endStr, 10, 64); err != nil {
+			return selector, Region{}, false
+		}
+	}
+	return selector[:i], region, true
+}
+
+// bounds returns 0-based half-open interval of region in a sequence of given length.
+// The end is clipped to the sequence length.
+func (r Region) bounds(length int64) (int64, int64, error) {
+	end := r.End
+	if end == 0 || end > length {
+		end = length
+	}
+	if r.Start < 1 || r.End != 0 && r.End < r.Start {
+		return 0, 0, errors.Wrapf(ErrBadRegionBounds, "%d-%d", r.Start, r.End)
+	}
+	if r.Start > length {
+		return 0, 0, errors.Wrapf(ErrRegionOutOfSeq, "sequence has %d bases", length)
+	}
+	return r.Start - 1, end, nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type FaiTestSuite struct {
+	suite.Suite
+}
+
+func (s *FaiTestSuite) TestBuildFastaIndex() {
+	for _, c := range []struct {
+		input   string
+		exp     string
+		expErr  error
+		comment string
+	}{
+		{
+			input:   ">chr1 first\nACGTACGTAC\nGGGGCCCCTT\nAAA\n>chr2\nTTTTT\nCC\n",
+			exp:     "chr1\t23\t12\t10\t11\nchr2\t7\t44\t5\t6\n",
+			comment: "совпадает с samtools faidx",
+		},
+		{
+			input:   ">a\r\nACG\r\nT\r\n\r\n>b\r\nGG",
+			exp:     "a\t4\t4\t3\t5\nb\t2\t18\t2\t4\n",
+			comment: "окончания строк \\r\\n, пустая строка и последняя строка без перевода строки",
+		},
+		{
+			input:   ">a\nACG\nT\nACG\n",
+			expErr:  ErrUnevenLines,
+			comment: "короткая строка в середине записи",
+		},
+		{
+			input:   ">a\nACG\nACGT\n",
+			expErr:  ErrUnevenLines,
+			comment: "длинная строка в середине записи",
+		},
+		{
+			input:  "ACGT\n",
+			expErr: ErrIndexNotFasta,
+		},
+	} {
+		idx, err := BuildFastaIndex(strings.NewReader(c.input))
+		if c.expErr != nil {
+			s.Equal(c.expErr, errors.Cause(err), c.comment)
+			continue
+		}
+		s.Require().NoError(err, c.comment)
+
+		var buf bytes.Buffer
+		s.Require().NoError(idx.Write(&buf))
+		s.Equal(c.exp, buf.String(), c.comment)
+
+		read, err := ReadFastaIndex(&buf)
+		s.Require().NoError(err, c.comment)
+		s.Equal(idx.Entries, read.Entries, c.comment)
+	}
+}
+
+func (s *FaiTestSuite) TestFetch() {
+	const input = ">chr1 first\r\nACGTACGTAC\r\nGGGGCCCCTT\r\nAAA\r\n"
+	idx, err := BuildFastaIndex(strings.NewReader(input))
+	s.Require().NoError(err)
+	entry, err := idx.Lookup("#1")
+	s.Require().NoError(err)
+
+	r := strings.NewReader(input)
+	for _, c := range []struct {
+		start, end int64
+		exp        string
+	}{
+		{0, 23, "ACGTACGTACGGGGCCCCTTAAA"},
+		{7, 14, "TACGGGG"},
+		{10, 10, ""},
+		{19, 21, "TA"},
+	} {
+		value, err := entry.Fetch(r, c.start, c.end)
+		s.Require().NoError(err)
+		s.Equal(c.exp, value)
+	}
+
+	_, err = entry.Fetch(r, 20, 24)
+	s.Equal(ErrRegionOutOfSeq, errors.Cause(err))
+	_, err = idx.Lookup("chr2")
+	s.Equal(ErrIndexNoRecord, errors.Cause(err))
+}
+
+func (s *FaiTestSuite) TestParseRegion() {
+	for _, c := range []struct {
+		selector string
+		expName  string
+		exp      Region
+		expOk    bool
+	}{
+		{"chr1:1000-5000", "chr1", Region{1000, 5000}, true},
+		{"chr1:1,000-5,000", "chr1", Region{1000, 5000}, true},
+		{"chr1:1000", "chr1", Region{1000, 0}, true},
+		{"HLA:A:10-20", "HLA:A", Region{10, 20}, true},
+		{"#2:5-6", "#2", Region{5, 6}, true},
+		{"chr1", "chr1", Region{}, false},
+		{"HLA:A", "HLA:A", Region{}, false},
+	} {
+		name, region, ok := ParseRegion(c.selector)
+		s.Equal(c.expOk, ok, c.selector)
+		s.Equal(c.expName, name, c.selector)
+		s.Equal(c.exp, region, c.selector)
+	}
+}
+
+func (s *FaiTestSuite) TestLoadRegion() {
+	data, err := os.ReadFile("testdata/region.fa")
+	s.Require().NoError(err)
+	file := filepath.Join(s.T().TempDir(), "region.fa")
+	s.Require().NoError(os.WriteFile(file, data, 0o644))
+
+	check := func(comment string) {
+		seqs, err := loadSequences(nil, &SelectConfig{Seq1: file + ":chr1:8-14", Seq2: file + ":#2:2"}, nil)
+		s.Require().NoError(err, comment)
+		s.Equal("chr1:8-14", seqs[0].Description, comment)
+		s.Equal("TACGGGG", seqs[0].Value, comment)
+		s.Equal("chr2:2-7", seqs[1].Description, comment)
+		s.Equal("TTTTCC", seqs[1].Value, comment)
+
+		_, err = loadSequences(nil, &SelectConfig{Seq1: file + ":chr1:30", Seq2: file + ":chr2"}, nil)
+		s.Equal(ErrRegionOutOfSeq, errors.Cause(err), comment)
+		_, err = loadSequences(nil, &SelectConfig{Seq1: file + ":chr1:5-4", Seq2: file + ":chr2"}, nil)
+		s.Equal(ErrBadRegionBounds, errors.Cause(err), comment)
+	}
+
+	check("без индекса")
+	s.Require().NoError(indexFastaFile(file))
+	check("с индексом")
+}
+
+func TestFaiSuite(t *testing.T) {
+	suite.Run(t, new(FaiTestSuite))
+}
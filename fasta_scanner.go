@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// fastaScannerBufferSize is the size of FastaScanner read buffer,
+// sequence chunks are never longer than it
+const fastaScannerBufferSize = 64 * 1024
+
+// FastaScanner reads FASTA records with the same rules as FastaParser in FastaDefault mode,
+// but reuses its buffers instead of allocating strings for every line and record.
+//
+// Records are iterated with Scan, which collects the whole sequence into a reused buffer,
+// or with NextHeader and NextChunk, which hand out the sequence piece by piece
+// and never hold the whole record in memory.
+// Slices returned by Header, Value and Chunk are valid only until the next call of
+// Scan, NextHeader or NextChunk.
+type FastaScanner struct {
+	reader *bufio.Reader
+
+	header []byte
+	value  []byte
+	chunk  []byte
+	err    error
+	// lineBuf is reused for headers and skipped lines, chunk may point into the reader buffer
+	lineBuf []byte
+
+	// inRecord is true while sequence lines of the current record are not consumed
+	inRecord bool
+	// lineStart is true if the next unread byte starts a line
+	lineStart bool
+	// line is the number of lines started
+	line int
+	// record is the number of records started
+	record int
+}
+
+// NewFastaScanner returns new FastaScanner reading r
+func NewFastaScanner(r io.Reader) *FastaScanner {
+	return &FastaScanner{
+		reader:    bufio.NewReaderSize(r, fastaScannerBufferSize),
+		lineStart: true,
+	}
+}
+
+// Scan advances to the next record and reads its whole sequence.
+// Returns false at the end of input or on error, see Err.
+func (s *FastaScanner) Scan() bool {
+	if !s.NextHeader() {
+		return false
+	}
+
+	s.value = s.value[:0]
+	for s.NextChunk() {
+		s.value = append(s.value, s.chunk...)
+	}
+	return s.err == nil
+}
+
+// NextHeader advances to the next record without reading its sequence.
+// The rest of the current record is skipped.
+// Returns false at the end of input or on error, see Err.
+func (s *FastaScanner) NextHeader() bool {
+	for s.NextChunk() {
+	}
+	if s.err != nil {
+		return false
+	}
+
+	for {
+		first, err := s.reader.Peek(1)
+		if err != nil {
+			if err != io.EOF {
+				s.err = err
+			}
+			return false
+		}
+
+		if first[0] == '>' {
+			s.record++
+			s.value = s.value[:0]
+			if s.lineBuf, s.err = s.appendLine(s.lineBuf[:0]); s.err != nil {
+				return false
+			}
+			// comments inside the record reuse lineBuf, so the header is kept apart
+			s.header = append(s.header[:0], bytes.TrimSpace(s.lineBuf[1:])...)
+			s.inRecord = true
+			return true
+		}
+
+		// only blank lines and comments may precede a header
+		if s.lineBuf, s.err = s.appendLine(s.lineBuf[:0]); s.err != nil {
+			return false
+		}
+		if len(bytes.TrimSpace(s.lineBuf)) != 0 && s.lineBuf[0] != ';' {
+			s.record++
+			s.err = &ParseError{Record: s.record, Line: s.line, Column: 1, Err: ErrBadHeader}
+			return false
+		}
+	}
+}
+
+// NextChunk advances to the next piece of the current record sequence.
+// Chunks contain no whitespace and are not longer than the scanner buffer.
+// Returns false at the end of the record or on error, see Err.
+func (s *FastaScanner) NextChunk() bool {
+	for s.inRecord {
+		if s.lineStart {
+			first, err := s.reader.Peek(1)
+			if err != nil {
+				s.inRecord = false
+				if err != io.EOF {
+					s.err = err
+				}
+				return false
+			}
+			switch first[0] {
+			case '>':
+				s.inRecord = false
+				return false
+			case ';':
+				if s.lineBuf, s.err = s.appendLine(s.lineBuf[:0]); s.err != nil {
+					s.inRecord = false
+					return false
+				}
+				continue
+			}
+		}
+
+		raw, err := s.readSlice()
+		if err != nil {
+			s.inRecord = false
+			s.err = err
+			return false
+		}
+		// raw points into the reader buffer and is already consumed, so it is compacted in place
+		s.chunk = dropSpaces(raw)
+		if len(s.chunk) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Header returns the description of the current record
+func (s *FastaScanner) Header() []byte {
+	return s.header
+}
+
+// Value returns the sequence of the current record read by Scan
+func (s *FastaScanner) Value() []byte {
+	return s.value
+}
+
+// Chunk returns the sequence piece read by NextChunk
+func (s *FastaScanner) Chunk() []byte {
+	return s.chunk
+}
+
+// Err returns the first error met by the scanner, io.EOF is not an error
+func (s *FastaScanner) Err() error {
+	return s.err
+}
+
+// Sequence returns a copy of the current record read by Scan
+func (s *FastaScanner) Sequence() *Sequence {
+	return &Sequence{
+		Description: string(s.header),
+		Value:       string(s.value),
+	}
+}
+
+// readSlice reads the rest of the current line, but not more than the buffer holds.
+// Returns io.EOF only if there are no more bytes.
+func (s *FastaScanner) readSlice() ([]byte, error) {
+	if s.lineStart {
+		s.line++
+	}
+	raw, err := s.reader.ReadSlice('\n')
+	s.lineStart = err != bufio.ErrBufferFull
+	if err == bufio.ErrBufferFull || err == io.EOF && len(raw) > 0 {
+		err = nil
+	}
+	return raw, err
+}
+
+// appendLine appends the rest of the current line without line ending to dst
+func (s *FastaScanner) appendLine(dst []byte) ([]byte, error) {
+	for {
+		raw, err := s.readSlice()
+		if err != nil {
+			return dst, err
+		}
+		dst = append(dst, raw...)
+		if s.lineStart {
+			return bytes.TrimRight(dst, "\r\n"), nil
+		}
+	}
+}
+
+// dropSpaces removes whitespace from b in place
+func dropSpaces(b []byte) []byte {
+	res := b[:0]
+	for _, c := range b {
+		if !isSequenceSpace(c) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// isSequenceSpace reports whether b is whitespace inside a sequence.
+// Only ASCII whitespace counts: bytes above 0x7f are parts of multi-byte UTF-8 characters
+// and are never dropped, so sequences can be split at any byte.
+func isSequenceSpace(b byte) bool {
+	switch b {
+	case ' ', '\t', '\n', '\r', '\v', '\f':
+		return true
+	}
+	return false
+}
+
+// ScanFasta calls fn for every FASTA record of r.
+// header and value are valid only during the call and must be copied to be kept.
+// Scanning stops at the first error returned by fn.
+func ScanFasta(r io.Reader, fn func(header, value []byte) error) error {
+	scanner := NewFastaScanner(r)
+	for scanner.Scan() {
+		if err := fn(scanner.Header(), scanner.Value()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
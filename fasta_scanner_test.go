@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type FastaScannerTestSuite struct {
+	suite.Suite
+}
+
+func (s *FastaScannerTestSuite) TestScan() {
+	scanner := NewFastaScanner(strings.NewReader(
+		"; comment before records\n" +
+			"\n" +
+			">seq1 first \n" +
+			"AC GT\r\n" +
+			"; comment inside record\n" +
+			"A>C\n" +
+			"\n" +
+			">empty\n" +
+			">seq3\n" +
+			"TT",
+	))
+
+	var seqs []*Sequence
+	for scanner.Scan() {
+		seqs = append(seqs, scanner.Sequence())
+	}
+	s.Require().NoError(scanner.Err())
+	s.Equal([]*Sequence{
+		{Description: "seq1 first", Value: "ACGTA>C"},
+		{Description: "empty", Value: ""},
+		{Description: "seq3", Value: "TT"},
+	}, seqs)
+}
+
+func (s *FastaScannerTestSuite) TestMultiByte() {
+	// х и Р кодируются как D1 85 и D0 A0, вторые байты не должны считаться пробелами
+	scanner := NewFastaScanner(strings.NewReader(">а\nхорошо\n>б\nРЫБА\tрыба\n"))
+
+	var seqs []*Sequence
+	for scanner.Scan() {
+		seqs = append(seqs, scanner.Sequence())
+	}
+	s.Require().NoError(scanner.Err())
+	s.Equal([]*Sequence{
+		{Description: "а", Value: "хорошо"},
+		{Description: "б", Value: "РЫБАрыба"},
+	}, seqs)
+}
+
+func (s *FastaScannerTestSuite) TestBadHeader() {
+	scanner := NewFastaScanner(strings.NewReader("\n; comment\nACGT\n"))
+	s.False(scanner.Scan())
+	s.Equal(&ParseError{Record: 1, Line: 3, Column: 1, Err: ErrBadHeader}, scanner.Err())
+}
+
+func (s *FastaScannerTestSuite) TestHugeRecord() {
+	rnd := rand.New(rand.NewSource(42))
+	// строки длиннее буфера сканера
+	line1 := randomDNA(rnd, 3*fastaScannerBufferSize)
+	line2 := randomDNA(rnd, fastaScannerBufferSize/2)
+	header := strings.Repeat("h", 2*fastaScannerBufferSize)
+	input := ">" + header + "\n" + line1 + "\r\n" + line2 + "\n>tail\nACGT\n"
+
+	scanner := NewFastaScanner(strings.NewReader(input))
+	s.Require().True(scanner.NextHeader())
+	s.Equal(header, string(scanner.Header()))
+
+	var value bytes.Buffer
+	for scanner.NextChunk() {
+		s.LessOrEqual(len(scanner.Chunk()), fastaScannerBufferSize)
+		value.Write(scanner.Chunk())
+	}
+	s.Equal(line1+line2, value.String())
+
+	s.Require().True(scanner.Scan())
+	s.Equal(&Sequence{Description: "tail", Value: "ACGT"}, scanner.Sequence())
+	s.False(scanner.Scan())
+	s.NoError(scanner.Err())
+}
+
+func (s *FastaScannerTestSuite) TestNextHeaderSkipsRecords() {
+	scanner := NewFastaScanner(strings.NewReader(">a\nAC\nGT\n>b\nTT\n>c\n"))
+	var headers []string
+	for scanner.NextHeader() {
+		headers = append(headers, string(scanner.Header()))
+	}
+	s.NoError(scanner.Err())
+	s.Equal([]string{"a", "b", "c"}, headers)
+}
+
+func (s *FastaScannerTestSuite) TestScanFasta() {
+	errStop := errors.New("stop")
+	var ids []string
+	err := ScanFasta(strings.NewReader(">a\nAC\n>b\nGT\n>c\nTT\n"), func(header, value []byte) error {
+		ids = append(ids, string(header)+":"+string(value))
+		if len(ids) == 2 {
+			return errStop
+		}
+		return nil
+	})
+	s.Equal(errStop, err)
+	s.Equal([]string{"a:AC", "b:GT"}, ids)
+}
+
+func TestFastaScannerSuite(t *testing.T) {
+	suite.Run(t, new(FastaScannerTestSuite))
+}
+
+// benchmarkFasta возвращает fasta из count записей длины n со строками по 60 символов
+func benchmarkFasta(count, n int) []byte {
+	rnd := rand.New(rand.NewSource(42))
+	var buf bytes.Buffer
+	for i := 0; i < count; i++ {
+		fmt.Fprintf(&buf, ">seq%d random sequence\n", i)
+		value := randomDNA(rnd, n)
+		for len(value) > 60 {
+			buf.WriteString(value[:60])
+			buf.WriteByte('\n')
+			value = value[60:]
+		}
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+func benchmarkRecordReader(b *testing.B, data []byte, newReader func(r io.Reader) RecordReader) {
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		reader := newReader(bytes.NewReader(data))
+		for {
+			_, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkFastaParser(b *testing.B) {
+	data := benchmarkFasta(1000, 10000)
+	// в строгом режиме каждая строка проверяется и разбирается построчно, как раньше
+	b.Run("strict", func(b *testing.B) {
+		benchmarkRecordReader(b, data, func(r io.Reader) RecordReader {
+			return NewFastaParserMode(r, FastaStrict, nil)
+		})
+	})
+	b.Run("default", func(b *testing.B) {
+		benchmarkRecordReader(b, data, func(r io.Reader) RecordReader {
+			return NewFastaParser(r)
+		})
+	})
+}
+
+func BenchmarkFastaScanner(b *testing.B) {
+	data := benchmarkFasta(1000, 10000)
+	b.Run("scan", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			if err := ScanFasta(bytes.NewReader(data), func(header, value []byte) error { return nil }); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("chunks", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			scanner := NewFastaScanner(bytes.NewReader(data))
+			for scanner.NextHeader() {
+				for scanner.NextChunk() {
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Column layout of GenBank and EMBL feature tables after the line prefix
+const (
+	featureKeyColumn   = 5
+	featureValueColumn = 21
+)
+
+// Qualifier is a feature qualifier such as /gene="TCP1"
+type Qualifier struct {
+	Name  string
+	Value string
+}
+
+// Feature is an entry of a GenBank or EMBL feature table
+type Feature struct {
+	Key string
+	// Location is the raw location, e.g. complement(join(1..10,20..30))
+	Location string
+	// Start and End are 1-based inclusive bounds of the location
+	Start      int
+	End        int
+	Complement bool
+	Qualifiers []Qualifier
+}
+
+// Qualifier returns value of the first qualifier with the given name
+func (f *Feature) Qualifier(name string) (string, bool) {
+	for _, q := range f.Qualifiers {
+		if q.Name == name {
+			return q.Value, true
+		}
+	}
+	return "", false
+}
+
+// featureTableBuilder collects features from feature table lines
+// with the prefix (spaces for GenBank or "FT" for EMBL) already replaced by spaces
+type featureTableBuilder struct {
+	features []Feature
+	// openQuote is true while a quoted qualifier value continues on the next lines
+	openQuote bool
+}
+
+func (b *featureTableBuilder) addLine(line string) {
+	if len(line) <= featureKeyColumn {
+		return
+	}
+
+	key := strings.TrimSpace(line[featureKeyColumn:MinInt(len(line), featureValueColumn)])
+	value := ""
+	if len(line) > featureValueColumn {
+		value = strings.TrimSpace(line[featureValueColumn:])
+	}
+
+	switch {
+	case key != "":
+		b.features = append(b.features, Feature{Key: key, Location: value})
+		b.openQuote = false
+	case len(b.features) == 0:
+		// continuation without a feature, nothing to attach it to
+	case b.openQuote:
+		b.continueQualifier(value)
+	case strings.HasPrefix(value, "/"):
+		b.addQualifier(value[1:])
+	default:
+		last := &b.features[len(b.features)-1]
+		if len(last.Qualifiers) == 0 {
+			last.Location += value
+		}
+	}
+}
+
+func (b *featureTableBuilder) addQualifier(text string) {
+	last := &b.features[len(b.features)-1]
+	eq := strings.IndexByte(text, '=')
+	if eq < 0 {
+		last.Qualifiers = append(last.Qualifiers, Qualifier{Name: text})
+		return
+	}
+
+	q := Qualifier{Name: text[:eq], Value: text[eq+1:]}
+	if strings.HasPrefix(q.Value, "\"") {
+		q.Value = q.Value[1:]
+		b.openQuote = !strings.HasSuffix(q.Value, "\"")
+		if !b.openQuote {
+			q.Value = strings.TrimSuffix(q.Value, "\"")
+		}
+	}
+	last.Qualifiers = append(last.Qualifiers, q)
+}
+
+func (b *featureTableBuilder) continueQualifier(text string) {
+	last := &b.features[len(b.features)-1]
+	q := &last.Qualifiers[len(last.Qualifiers)-1]
+
+	if strings.HasSuffix(text, "\"") {
+		text = strings.TrimSuffix(text, "\"")
+		b.openQuote = false
+	}
+	// protein translations are wrapped without separators
+	if q.Name != "translation" {
+		q.Value += " "
+	}
+	q.Value += text
+}
+
+// build finishes features parsing locations into coordinates
+func (b *featureTableBuilder) build() []Feature {
+	for i := range b.features {
+		f := &b.features[i]
+		f.Complement = strings.HasPrefix(f.Location, "complement(")
+		f.Start, f.End = locationBounds(f.Location)
+	}
+	return b.features
+}
+
+// locationBounds returns minimal and maximal positions mentioned in location
+func locationBounds(location string) (int, int) {
+	start, end := 0, 0
+	for _, field := range strings.FieldsFunc(location, func(r rune) bool { return !unicode.IsDigit(r) }) {
+		pos, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+		if start == 0 || pos < start {
+			start = pos
+		}
+		end = MaxInt(end, pos)
+	}
+	return start, end
+}
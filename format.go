@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnknownOutputFormat возвращается для неизвестного формата вывода
+var ErrUnknownOutputFormat = errors.New("unknown output format")
+
+const (
+	defaultFormat = "default"
+	cigarFormat   = "cigar"
+	samFormat     = "sam"
+	pafFormat     = "paf"
+	embossFormat  = "emboss"
+	blast6Format  = "blast6"
+	blast7Format  = "blast7"
+	jsonFormat    = "json"
+	ndjsonFormat  = "ndjson"
+	htmlFormat    = "html"
+)
+
+// programName имя программы в заголовках форматов вывода
+const programName = "seq-aligner"
+
+// AlignmentRecord выравнивание пары записей.
+// Первая запись считается референсом, вторая — запросом.
+type AlignmentRecord struct {
+	Seq1 *Sequence
+	Seq2 *Sequence
+	*AlignResult
+}
+
+// Formatter записывает выравнивания в определённом формате
+type Formatter interface {
+	Format(w io.Writer, records []*AlignmentRecord) error
+}
+
+// ScoringInfo параметры оценки, с которыми получены выравнивания
+type ScoringInfo struct {
+	// Mode режим алфавита и таблицы оценок
+	Mode string
+	// Matrix название матрицы оценок
+	Matrix string
+	// Match и Mismatch оценки совпадения и несовпадения в режиме default
+	Match    int
+	Mismatch int
+	// Pairs файл с оценками отдельных пар символов или пустая строка
+	Pairs string
+	// GapOpen штраф за первый символ gap, GapExtend — за каждый следующий
+	GapOpen         int
+	GapExtend       int
+	GapStartPenalty bool
+	GapEndPenalty   bool
+	Local           bool
+	// QualityWeighted оценки совмещения умножены на вероятности правильного прочтения символов
+	QualityWeighted bool
+}
+
+// FormatConfig набор параметров форматов вывода
+type FormatConfig struct {
+	// Scorer оценивает совмещения символов, нужен для поиска похожих символов
+	Scorer Scorer
+	// Scoring параметры оценки для заголовков отчётов
+	Scoring *ScoringInfo
+	// LineLength длина строки выровненных последовательностей
+	LineLength int
+	// Pretty включает разноцветный вывод формата default
+	Pretty bool
+	// Theme выбирает цвета разноцветного вывода
+	Theme ColorTheme
+	// MatchLine выводит строку разметки между последовательностями в формате default
+	MatchLine bool
+	// Stats выводит статистику выравнивания после оценки в формате default
+	Stats bool
+	// ExtendedCigar различает в CIGAR совпадения '=' и замены 'X'
+	ExtendedCigar bool
+}
+
+// buildFormatter возвращает Formatter формата format.
+// За именем форматов blast6 и blast7 через пробел может следовать список колонок, как в -outfmt BLAST.
+func buildFormatter(format string, cfg *FormatConfig) (Formatter, error) {
+	fields := strings.Fields(format)
+	if len(fields) == 0 {
+		return nil, errors.Wrap(ErrUnknownOutputFormat, format)
+	}
+	switch fields[0] {
+	case blast6Format:
+		return newBlastFormatter(cfg, fields[1:], false)
+	case blast7Format:
+		return newBlastFormatter(cfg, fields[1:], true)
+	}
+	if len(fields) > 1 {
+		return nil, errors.Wrapf(ErrUnknownOutputFormat, "%s does not accept columns", fields[0])
+	}
+
+	switch format {
+	case defaultFormat:
+		return &defaultFormatter{cfg: cfg}, nil
+	case cigarFormat:
+		return &cigarFormatter{cfg: cfg}, nil
+	case samFormat:
+		return &samFormatter{cfg: cfg}, nil
+	case pafFormat:
+		return &pafFormatter{cfg: cfg}, nil
+	case embossFormat:
+		return &embossFormatter{cfg: cfg}, nil
+	case jsonFormat:
+		return &jsonFormatter{cfg: cfg}, nil
+	case ndjsonFormat:
+		return &jsonFormatter{cfg: cfg, lines: true}, nil
+	case htmlFormat:
+		return &htmlFormatter{cfg: cfg}, nil
+	}
+	return nil, errors.Wrap(ErrUnknownOutputFormat, format)
+}
+
+// defaultFormatter выводит выровненные строки и оценку
+type defaultFormatter struct {
+	cfg *FormatConfig
+}
+
+func (f *defaultFormatter) Format(w io.Writer, records []*AlignmentRecord) error {
+	for _, rec := range records {
+		// в пакетном режиме выравнивания отделяются заголовками
+		if len(records) > 1 {
+			if _, err := fmt.Fprintf(w, "# %s vs %s\n", recordName(rec.Seq1, "seq1"), recordName(rec.Seq2, "seq2")); err != nil {
+				return err
+			}
+		}
+		var err error
+		if f.cfg.Pretty {
+			err = WritePretty(w, f.cfg.LineLength, f.cfg.Scorer, f.cfg.Theme, rec.AlignResult)
+		} else if f.cfg.MatchLine {
+			err = WriteAlignedMatch(w, f.cfg.LineLength, f.cfg.Scorer, rec.Aligned1, rec.Aligned2)
+		} else {
+			err = WriteAlignedDefault(w, f.cfg.LineLength, rec.Aligned1, rec.Aligned2)
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "Score: %d\n", rec.Score); err != nil {
+			return err
+		}
+		if f.cfg.Stats {
+			dna := f.cfg.Scoring != nil && f.cfg.Scoring.Mode == dnaMode
+			if err := ComputeStats(rec.AlignResult, f.cfg.Scorer).Write(w, dna); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cigarFormatter выводит для каждого выравнивания строку с колонками, разделёнными табуляцией:
+// идентификатор референса, позиция начала в референсе (с 1), идентификатор запроса, CIGAR и оценка.
+// В локальном выравнивании концевые gap отбрасываются, а символы запроса напротив них уходят в soft clip,
+// глобальное выводится целиком, чтобы оценка относилась к колонкам CIGAR, включая концевые gap.
+type cigarFormatter struct {
+	cfg *FormatConfig
+}
+
+func (f *cigarFormatter) Format(w io.Writer, records []*AlignmentRecord) error {
+	local := f.cfg.Scoring != nil && f.cfg.Scoring.Local
+	for _, rec := range records {
+		res := rec.AlignResult
+		if local {
+			res = res.trimGaps()
+		}
+		_, err := fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%d\n",
+			recordName(rec.Seq1, "seq1"), res.Start1+1, recordName(rec.Seq2, "seq2"), NewCigar(res, f.cfg.ExtendedCigar), res.Score)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordName возвращает идентификатор записи или fallback, если у записи нет описания
+func recordName(seq *Sequence, fallback string) string {
+	if id := sequenceID(seq); id != "" {
+		return id
+	}
+	return fallback
+}
+
+// columnScores возвращает вклад каждой колонки выравнивания res в оценку по тем же правилам, что и выравниватели:
+// совмещение оценивается scorer, первый символ gap штрафуется scoring.GapOpen, следующие — scoring.GapExtend.
+// Gap в начале и в конце последовательностей не штрафуются без GapStartPenalty и GapEndPenalty.
+// Веса качества прочтения не учитываются.
+func columnScores(res *AlignResult, scorer Scorer, scoring *ScoringInfo) []int {
+	runes1, runes2 := []rune(res.Aligned1), []rune(res.Aligned2)
+	scores := make([]int, len(runes1))
+
+	// i и j число символов первой и второй последовательности перед текущей колонкой
+	i, j := res.Start1, res.Start2
+	for k := range runes1 {
+		switch {
+		case runes1[k] == gapRune:
+			extend := k > 0 && runes1[k-1] == gapRune
+			scores[k] = gapColumnPenalty(scoring, i, res.Len1, extend)
+			j++
+		case runes2[k] == gapRune:
+			extend := k > 0 && runes2[k-1] == gapRune
+			scores[k] = gapColumnPenalty(scoring, j, res.Len2, extend)
+			i++
+		default:
+			scores[k] = scoreRunes(scorer, runes1[k], runes2[k])
+			i++
+			j++
+		}
+	}
+	return scores
+}
+
+// gapColumnPenalty возвращает штраф за символ gap после pos символов последовательности длины length
+func gapColumnPenalty(scoring *ScoringInfo, pos, length int, extend bool) int {
+	if !scoring.GapStartPenalty && pos == 0 {
+		return 0
+	}
+	// в начале последовательности штраф за конец не снимается даже у пустой последовательности
+	if !scoring.GapEndPenalty && pos == length && pos != 0 {
+		return 0
+	}
+	if extend {
+		return scoring.GapExtend
+	}
+	return scoring.GapOpen
+}
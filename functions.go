@@ -30,12 +30,3 @@ func MaxOfThreeInt(a, b, c int) (int, int) {
 
 	return c, 2
 }
-
-// Reverse разворот строки
-func Reverse(s string) string {
-	runes := []rune(s)
-	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
-		runes[i], runes[j] = runes[j], runes[i]
-	}
-	return string(runes)
-}
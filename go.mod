@@ -1,9 +1,18 @@
 module github.com/GDVFox/seq-aligner
 
-go 1.14
+go 1.18
 
 require (
 	github.com/fatih/color v1.9.0
+	github.com/mattn/go-isatty v0.0.11
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.6.1
+	golang.org/x/sys v0.0.0-20191026070338-33540a1f6037
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/mattn/go-colorable v0.1.4 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
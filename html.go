@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// htmlPlotWidth и htmlPlotHeight размеры графика оценки в пикселях
+	htmlPlotWidth  = 600
+	htmlPlotHeight = 120
+)
+
+// htmlColumn колонка выравнивания в отчёте
+type htmlColumn struct {
+	Symbol string
+	// Class класс раскраски: m — совпадение, s — похожие символы, x — несовпадение, g — gap
+	Class string
+	// Title подсказка с позициями символов и вкладом колонки в оценку
+	Title string
+}
+
+// htmlLine строка выравнивания в отчёте с номерами первого и последнего символа (с 1)
+type htmlLine struct {
+	Name    string
+	Start   int
+	End     int
+	Columns []htmlColumn
+}
+
+// htmlBlock блок из строк первой последовательности, разметки и второй последовательности
+type htmlBlock struct {
+	Seq1   htmlLine
+	Markup string
+	Seq2   htmlLine
+}
+
+// htmlRow строка таблицы сводки
+type htmlRow struct {
+	Name  string
+	Value string
+}
+
+// htmlAlignment выравнивание пары записей в отчёте
+type htmlAlignment struct {
+	Title   string
+	Summary []htmlRow
+	Blocks  []htmlBlock
+	// Points точки ломаной накопленной оценки в координатах SVG
+	Points string
+	// Zero координата y нулевой оценки на графике
+	Zero    int
+	MinText string
+	MaxText string
+}
+
+// htmlTemplate шаблон отчёта: один файл без внешних стилей и скриптов
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Program}} report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table.summary { border-collapse: collapse; margin-bottom: 1em; }
+table.summary td { border: 1px solid #ccc; padding: 2px 8px; }
+table.summary td:first-child { font-weight: bold; background: #f4f4f4; }
+pre.alignment { font-family: monospace; line-height: 1.3; }
+pre.alignment .name { color: #555; }
+pre.alignment .pos { color: #888; }
+.m { color: #1a7f37; }
+.s { color: #9a6700; }
+.x { color: #0550ae; }
+.g { color: #cf222e; }
+svg.score { border: 1px solid #ccc; }
+</style>
+</head>
+<body>
+<h1>{{.Program}}</h1>
+{{range .Alignments}}<section>
+<h2>{{.Title}}</h2>
+<table class="summary">
+{{range .Summary}}<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>
+<pre class="alignment">
+{{range .Blocks}}{{template "line" .Seq1}}
+{{printf "%*s" $.Indent ""}}{{.Markup}}
+{{template "line" .Seq2}}
+
+{{end}}</pre>
+<svg class="score" xmlns="http://www.w3.org/2000/svg" width="{{$.PlotWidth}}" height="{{$.PlotHeight}}" viewBox="0 0 {{$.PlotWidth}} {{$.PlotHeight}}">
+<title>score along the alignment: min {{.MinText}}, max {{.MaxText}}</title>
+<line x1="0" y1="{{.Zero}}" x2="{{$.PlotWidth}}" y2="{{.Zero}}" stroke="#ccc"/>
+<polyline fill="none" stroke="#0550ae" stroke-width="1.5" points="{{.Points}}"/>
+</svg>
+</section>
+{{end}}</body>
+</html>
+{{define "line"}}<span class="name">{{printf "%-13.13s" .Name}}</span> <span class="pos">{{printf "%6d" .Start}}</span> {{range .Columns}}<span class="{{.Class}}" title="{{.Title}}">{{.Symbol}}</span>{{end}} <span class="pos">{{printf "%6d" .End}}</span>{{end}}
+`))
+
+// htmlFormatter выводит выравнивания самодостаточным отчётом HTML со сводкой,
+// раскрашенным выравниванием и графиком оценки
+type htmlFormatter struct {
+	cfg *FormatConfig
+}
+
+func (f *htmlFormatter) Format(w io.Writer, records []*AlignmentRecord) error {
+	alignments := make([]*htmlAlignment, 0, len(records))
+	for _, rec := range records {
+		alignments = append(alignments, f.alignment(rec))
+	}
+
+	bw := bufio.NewWriter(w)
+	err := htmlTemplate.Execute(bw, map[string]interface{}{
+		"Program":    programName,
+		"Alignments": alignments,
+		// отступ строки разметки: имя, пробел, номер и пробел
+		"Indent":     embossNameWidth + 8,
+		"PlotWidth":  htmlPlotWidth,
+		"PlotHeight": htmlPlotHeight,
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (f *htmlFormatter) alignment(rec *AlignmentRecord) *htmlAlignment {
+	name1, name2 := recordName(rec.Seq1, "seq1"), recordName(rec.Seq2, "seq2")
+	stats := ComputeStats(rec.AlignResult, f.cfg.Scorer)
+	res := &htmlAlignment{
+		Title: name1 + " vs " + name2,
+		Summary: []htmlRow{
+			{"Sequence 1", rec.Seq1.Description},
+			{"Sequence 2", rec.Seq2.Description},
+			{"Length 1", strconv.Itoa(rec.Len1)},
+			{"Length 2", strconv.Itoa(rec.Len2)},
+			{"Score", strconv.Itoa(rec.Score)},
+			{"Alignment length", strconv.Itoa(stats.Length)},
+			{"Identity", fmt.Sprintf("%d/%d (%s)", stats.Identity, stats.Length, percent(stats.Identity, stats.Length))},
+			{"Similarity", fmt.Sprintf("%d/%d (%s)", stats.Similarity, stats.Length, percent(stats.Similarity, stats.Length))},
+			{"Gaps", fmt.Sprintf("%d/%d (%s)", stats.Gaps, stats.Length, percent(stats.Gaps, stats.Length))},
+		},
+	}
+	scoring := f.cfg.Scoring
+	if scoring == nil {
+		// без параметров оценки вклад gap неизвестен, показываются только оценки совмещений
+		scoring = &ScoringInfo{}
+	} else {
+		res.Summary = append(res.Summary,
+			htmlRow{"Matrix", scoring.Matrix},
+			htmlRow{"Gap open", strconv.Itoa(scoring.GapOpen)},
+			htmlRow{"Gap extend", strconv.Itoa(scoring.GapExtend)},
+			htmlRow{"Local", strconv.FormatBool(scoring.Local)},
+		)
+	}
+
+	scores := columnScores(rec.AlignResult, f.cfg.Scorer, scoring)
+	runes1, runes2 := []rune(rec.Aligned1), []rune(rec.Aligned2)
+	lineLength := f.cfg.LineLength
+	if lineLength <= 0 {
+		lineLength = embossLineLength
+	}
+
+	pos1, pos2 := rec.Start1, rec.Start2
+	for l := 0; l < len(runes1); l += lineLength {
+		r := MinInt(len(runes1), l+lineLength)
+		block := htmlBlock{
+			Seq1: htmlLine{Name: name1, Start: pos1},
+			Seq2: htmlLine{Name: name2, Start: pos2},
+		}
+		markup := make([]rune, 0, r-l)
+		for k := l; k < r; k++ {
+			a, b := runes1[k], runes2[k]
+			if a != gapRune {
+				pos1++
+			}
+			if b != gapRune {
+				pos2++
+			}
+			symbol := markupSymbol(f.cfg.Scorer, a, b)
+			markup = append(markup, symbol)
+
+			class := htmlClass(symbol)
+			title := fmt.Sprintf("%s %s / %s %s: %+d",
+				name1, htmlPosition(a, pos1), name2, htmlPosition(b, pos2), scores[k])
+			block.Seq1.Columns = append(block.Seq1.Columns, htmlColumn{Symbol: string(a), Class: class, Title: title})
+			block.Seq2.Columns = append(block.Seq2.Columns, htmlColumn{Symbol: string(b), Class: class, Title: title})
+		}
+		block.Markup = string(markup)
+		block.Seq1.End, block.Seq2.End = pos1, pos2
+		// как в EMBOSS, номер первого символа строки считается с 1, а в строке из одних gap повторяется номер последнего
+		if block.Seq1.End > block.Seq1.Start {
+			block.Seq1.Start++
+		}
+		if block.Seq2.End > block.Seq2.Start {
+			block.Seq2.Start++
+		}
+		res.Blocks = append(res.Blocks, block)
+	}
+
+	res.plotScore(scores)
+	return res
+}
+
+// plotScore строит ломаную накопленной оценки по вкладам колонок scores
+func (a *htmlAlignment) plotScore(scores []int) {
+	cumulative := make([]int, len(scores)+1)
+	minScore, maxScore := 0, 0
+	for i, score := range scores {
+		cumulative[i+1] = cumulative[i] + score
+		minScore = MinInt(minScore, cumulative[i+1])
+		maxScore = MaxInt(maxScore, cumulative[i+1])
+	}
+	a.MinText, a.MaxText = strconv.Itoa(minScore), strconv.Itoa(maxScore)
+
+	// поля по 4 пикселя сверху и снизу, чтобы линия не сливалась с рамкой
+	const margin = 4
+	span := MaxInt(maxScore-minScore, 1)
+	y := func(score int) int {
+		return margin + (maxScore-score)*(htmlPlotHeight-2*margin)/span
+	}
+	a.Zero = y(0)
+
+	points := make([]string, len(cumulative))
+	steps := MaxInt(len(scores), 1)
+	for i, score := range cumulative {
+		points[i] = fmt.Sprintf("%d,%d", i*htmlPlotWidth/steps, y(score))
+	}
+	a.Points = strings.Join(points, " ")
+}
+
+// htmlClass возвращает класс раскраски колонки по символу разметки
+func htmlClass(markup rune) string {
+	switch markup {
+	case markupIdentity:
+		return "m"
+	case markupSimilar:
+		return "s"
+	case markupGap:
+		return "g"
+	}
+	return "x"
+}
+
+// htmlPosition возвращает номер символа r (с 1) или "gap"
+func htmlPosition(r rune, pos int) string {
+	if r == gapRune {
+		return "gap"
+	}
+	return strconv.Itoa(pos)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HTMLTestSuite struct {
+	suite.Suite
+}
+
+func (s *HTMLTestSuite) TestColumnScores() {
+	scorer := NewDefaultAdapter(2, -1)
+	res := &AlignResult{Aligned1: "--ACGT-T", Aligned2: "GGACCTAT", End1: 6, End2: 8, Len1: 6, Len2: 8}
+
+	s.Equal([]int{0, 0, 2, 2, -1, 2, -5, 2}, columnScores(res, scorer, &ScoringInfo{GapOpen: -5, GapExtend: -1}))
+	s.Equal([]int{-5, -1, 2, 2, -1, 2, -5, 2},
+		columnScores(res, scorer, &ScoringInfo{GapOpen: -5, GapExtend: -1, GapStartPenalty: true}))
+
+	// сумма вкладов совпадает с оценкой выравнивателя
+	aligner := NewSequenceAlignerExtend(&SequenceAlignerExtendConfig{
+		SequenceAlignerConfig: SequenceAlignerConfig{GapPenalty: -5},
+		ExtendGapPenalty:      -1,
+	}, scorer)
+	res = alignDetailed(aligner, "ACGTTTTACGT", "ACGACGT")
+	sum := 0
+	for _, score := range columnScores(res, scorer, &ScoringInfo{GapOpen: -5, GapExtend: -1}) {
+		sum += score
+	}
+	s.Equal(res.Score, sum)
+}
+
+func (s *HTMLTestSuite) TestFormat() {
+	scorer := NewProteinAdapterBLOSUM62()
+	seq1 := &Sequence{Description: "p1 <first> protein", Value: "HEAGAWGHEI"}
+	seq2 := &Sequence{Description: "p2", Value: "PAWHEV"}
+	aligner := NewSequenceAligner(&SequenceAlignerConfig{GapPenalty: -8, AllowLocal: true}, scorer)
+
+	var buf bytes.Buffer
+	err := (&htmlFormatter{cfg: &FormatConfig{
+		Scorer:     scorer,
+		Scoring:    &ScoringInfo{Matrix: "BLOSUM62", GapOpen: -8, GapExtend: -8, Local: true},
+		LineLength: 4,
+	}}).Format(&buf, []*AlignmentRecord{{
+		Seq1:        seq1,
+		Seq2:        seq2,
+		AlignResult: alignDetailed(aligner, seq1.Value, seq2.Value),
+	}})
+	s.Require().NoError(err)
+
+	out := buf.String()
+	s.Contains(out, "<td>Sequence 1</td><td>p1 &lt;first&gt; protein</td>")
+	s.Contains(out, "<td>Identity</td><td>4/6 (66.7%)</td>")
+	s.Contains(out, `<span class="m" title="p1 5 / p2 2: &#43;4">A</span>`)
+	s.Contains(out, `<span class="g" title="p1 7 / p2 gap: -8">G</span>`)
+	s.Contains(out, `<span class="pos">     5</span>`)
+	s.Contains(out, `<span class="pos">     8</span>`)
+	s.Contains(out, "<polyline")
+	s.NotContains(out, "<script")
+}
+
+func TestHTMLSuite(t *testing.T) {
+	suite.Run(t, new(HTMLTestSuite))
+}
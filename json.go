@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// jsonSequence описание выровненной записи
+type jsonSequence struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Length      int    `json:"length"`
+}
+
+// jsonStats число и доля колонок выравнивания каждого вида
+type jsonStats struct {
+	Length            int     `json:"length"`
+	Identity          int     `json:"identity"`
+	Similarity        int     `json:"similarity"`
+	Mismatches        int     `json:"mismatches"`
+	Gaps              int     `json:"gaps"`
+	GapOpens          int     `json:"gap_opens"`
+	IdentityPercent   float64 `json:"identity_percent"`
+	SimilarityPercent float64 `json:"similarity_percent"`
+	GapsPercent       float64 `json:"gaps_percent"`
+}
+
+// jsonScoring параметры оценки выравнивания
+type jsonScoring struct {
+	Mode            string `json:"mode"`
+	Matrix          string `json:"matrix"`
+	Match           *int   `json:"match,omitempty"`
+	Mismatch        *int   `json:"mismatch,omitempty"`
+	Pairs           string `json:"pairs,omitempty"`
+	GapOpen         int    `json:"gap_open"`
+	GapExtend       int    `json:"gap_extend"`
+	GapStartPenalty bool   `json:"gap_start_penalty"`
+	GapEndPenalty   bool   `json:"gap_end_penalty"`
+	Local           bool   `json:"local"`
+	QualityWeighted bool   `json:"quality_weighted"`
+}
+
+// jsonAlignment выравнивание пары записей.
+// Координаты отсчитываются с 0, конец не включается.
+type jsonAlignment struct {
+	Seq1     jsonSequence `json:"seq1"`
+	Seq2     jsonSequence `json:"seq2"`
+	Aligned1 string       `json:"aligned1"`
+	Aligned2 string       `json:"aligned2"`
+	Cigar    string       `json:"cigar"`
+	Start1   int          `json:"start1"`
+	End1     int          `json:"end1"`
+	Start2   int          `json:"start2"`
+	End2     int          `json:"end2"`
+	Score    int          `json:"score"`
+	Stats    jsonStats    `json:"stats"`
+	Scoring  *jsonScoring `json:"scoring,omitempty"`
+}
+
+// jsonDocument результат запуска в формате json, схема описана в schema/alignment.schema.json
+type jsonDocument struct {
+	Program    string           `json:"program"`
+	Alignments []*jsonAlignment `json:"alignments"`
+}
+
+// jsonFormatter выводит выравнивания одним документом JSON или, если lines, по объекту JSON в строке
+type jsonFormatter struct {
+	cfg   *FormatConfig
+	lines bool
+}
+
+func (f *jsonFormatter) Format(w io.Writer, records []*AlignmentRecord) error {
+	bw := bufio.NewWriter(w)
+	if f.lines {
+		enc := json.NewEncoder(bw)
+		for _, rec := range records {
+			if err := enc.Encode(f.alignment(rec)); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	}
+
+	doc := &jsonDocument{
+		Program:    programName,
+		Alignments: make([]*jsonAlignment, 0, len(records)),
+	}
+	for _, rec := range records {
+		doc.Alignments = append(doc.Alignments, f.alignment(rec))
+	}
+	enc := json.NewEncoder(bw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (f *jsonFormatter) alignment(rec *AlignmentRecord) *jsonAlignment {
+	stats := ComputeStats(rec.AlignResult, f.cfg.Scorer)
+	return &jsonAlignment{
+		Seq1:     jsonSequence{ID: sequenceID(rec.Seq1), Description: rec.Seq1.Description, Length: rec.Len1},
+		Seq2:     jsonSequence{ID: sequenceID(rec.Seq2), Description: rec.Seq2.Description, Length: rec.Len2},
+		Aligned1: rec.Aligned1,
+		Aligned2: rec.Aligned2,
+		Cigar:    NewCigar(rec.AlignResult, f.cfg.ExtendedCigar).String(),
+		Start1:   rec.Start1,
+		End1:     rec.End1,
+		Start2:   rec.Start2,
+		End2:     rec.End2,
+		Score:    rec.Score,
+		Stats: jsonStats{
+			Length:            stats.Length,
+			Identity:          stats.Identity,
+			Similarity:        stats.Similarity,
+			Mismatches:        stats.Mismatch,
+			Gaps:              stats.Gaps,
+			GapOpens:          stats.GapOpens,
+			IdentityPercent:   stats.IdentityPercent(),
+			SimilarityPercent: stats.SimilarityPercent(),
+			GapsPercent:       stats.GapsPercent(),
+		},
+		Scoring: newJSONScoring(f.cfg.Scoring),
+	}
+}
+
+func newJSONScoring(info *ScoringInfo) *jsonScoring {
+	if info == nil {
+		return nil
+	}
+	res := &jsonScoring{
+		Mode:            info.Mode,
+		Matrix:          info.Matrix,
+		Pairs:           info.Pairs,
+		GapOpen:         info.GapOpen,
+		GapExtend:       info.GapExtend,
+		GapStartPenalty: info.GapStartPenalty,
+		GapEndPenalty:   info.GapEndPenalty,
+		Local:           info.Local,
+		QualityWeighted: info.QualityWeighted,
+	}
+	// оценки совпадения и несовпадения используются только в режиме default
+	if info.Mode == defaultMode {
+		match, mismatch := info.Match, info.Mismatch
+		res.Match, res.Mismatch = &match, &mismatch
+	}
+	return res
+}
+
+// fraction возвращает долю part от total в процентах
+func fraction(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(part) / float64(total)
+}
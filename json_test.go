@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type JSONTestSuite struct {
+	suite.Suite
+	schema map[string]interface{}
+}
+
+func (s *JSONTestSuite) SetupSuite() {
+	data, err := os.ReadFile("schema/alignment.schema.json")
+	s.Require().NoError(err)
+	s.Require().NoError(json.Unmarshal(data, &s.schema))
+}
+
+func (s *JSONTestSuite) format(lines bool) string {
+	seq1 := &Sequence{Description: "r reference", Value: "TTTACGTACGTTT"}
+	seq2 := &Sequence{Description: "q", Value: "GGACGACGTGG"}
+	scorer := NewDefaultAdapter(1, -1)
+	aligner := NewSequenceAligner(&SequenceAlignerConfig{GapPenalty: -1, AllowLocal: true}, scorer)
+	rec := &AlignmentRecord{
+		Seq1:        seq1,
+		Seq2:        seq2,
+		AlignResult: alignDetailed(aligner, seq1.Value, seq2.Value),
+	}
+
+	var buf bytes.Buffer
+	err := (&jsonFormatter{cfg: &FormatConfig{
+		Scorer:  scorer,
+		Scoring: &ScoringInfo{Mode: defaultMode, Matrix: "match/mismatch +1/-1", Match: 1, Mismatch: -1, GapOpen: -1, GapExtend: -1, Local: true},
+	}, lines: lines}).Format(&buf, []*AlignmentRecord{rec, rec})
+	s.Require().NoError(err)
+	return buf.String()
+}
+
+// checkSchema проверяет наличие обязательных и отсутствие неописанных полей value по схеме node
+func (s *JSONTestSuite) checkSchema(node map[string]interface{}, value interface{}, path string) {
+	if ref, ok := node["$ref"].(string); ok {
+		defs := s.schema["$defs"].(map[string]interface{})
+		node = defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+	}
+
+	switch node["type"] {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		s.Require().True(ok, path)
+		props := node["properties"].(map[string]interface{})
+		for _, name := range node["required"].([]interface{}) {
+			s.Contains(obj, name, path)
+		}
+		for name, v := range obj {
+			s.Require().Contains(props, name, path)
+			s.checkSchema(props[name].(map[string]interface{}), v, path+"."+name)
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		s.Require().True(ok, path)
+		for _, v := range arr {
+			s.checkSchema(node["items"].(map[string]interface{}), v, path+"[]")
+		}
+	case "string":
+		s.IsType("", value, path)
+	case "integer", "number":
+		s.IsType(float64(0), value, path)
+	case "boolean":
+		s.IsType(true, value, path)
+	}
+}
+
+func (s *JSONTestSuite) TestJSON() {
+	var doc map[string]interface{}
+	s.Require().NoError(json.Unmarshal([]byte(s.format(false)), &doc))
+	s.checkSchema(s.schema, doc, "$")
+
+	alignments := doc["alignments"].([]interface{})
+	s.Len(alignments, 2)
+	first := alignments[0].(map[string]interface{})
+	s.Equal("ACGTACGT", first["aligned1"])
+	s.Equal("ACG-ACGT", first["aligned2"])
+	s.Equal("2S3M1D4M2S", first["cigar"])
+	s.Equal(float64(6), first["score"])
+	s.Equal(87.5, first["stats"].(map[string]interface{})["identity_percent"])
+}
+
+func (s *JSONTestSuite) TestNDJSON() {
+	lines := strings.Split(strings.TrimSuffix(s.format(true), "\n"), "\n")
+	s.Len(lines, 2)
+	alignment := s.schema["$defs"].(map[string]interface{})["alignment"].(map[string]interface{})
+	for _, line := range lines {
+		var obj map[string]interface{}
+		s.Require().NoError(json.Unmarshal([]byte(line), &obj))
+		s.checkSchema(alignment, obj, "$")
+		s.Equal("r", obj["seq1"].(map[string]interface{})["id"])
+	}
+}
+
+func TestJSONSuite(t *testing.T) {
+	suite.Run(t, new(JSONTestSuite))
+}
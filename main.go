@@ -4,14 +4,20 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"os"
+	"regexp"
+
+	"github.com/fatih/color"
 )
 
 // Aligner интерфейс объекта, умеющего выравнивать строки
 type Aligner interface {
 	Align(str1, str2 string) (string, string, int)
+	// AlignDetailed выравнивает строки, умножая оценку совмещения i-го и j-го символов на w1[i]*w2[j]
+	// (nil вместо весов означает единичные веса), и возвращает выравнивание вместе с координатами.
+	// Если число весов не совпадает с числом символов, возвращает ErrWeightsLength.
+	AlignDetailed(str1, str2 string, w1, w2 []float64) (*AlignResult, error)
 }
 
 // ErrWrongNumberOfFiles возвращается
@@ -19,6 +25,12 @@ var (
 	ErrWrongNumberOfFiles = errors.New("expected one or two sequences files")
 )
 
+const (
+	fastaDefaultMode = "default"
+	fastaStrictMode  = "strict"
+	fastaLenientMode = "lenient"
+)
+
 const (
 	dnaMode         = "dna"
 	proteinB62Mode  = "protein_b62"
@@ -31,9 +43,16 @@ var (
 	extendGapValue int
 	allowLocal     bool
 
-	mode string
+	mode          string
+	matchValue    int
+	mismatchValue int
+	pairsFile     string
 
 	pretty     bool
+	colorMode  string
+	colorTheme string
+	matchLine  bool
+	showStats  bool
 	lineLength int
 	outputFile string
 
@@ -41,6 +60,21 @@ var (
 	endPenalty   bool
 
 	memSave bool
+
+	qualityAware bool
+
+	fastaMode string
+
+	seq1Spec      string
+	seq2Spec      string
+	selectPattern string
+
+	outputFormat  string
+	extendedCigar bool
+
+	batchMode bool
+
+	dumpFormat string
 )
 
 func init() {
@@ -50,9 +84,16 @@ func init() {
 	flag.BoolVar(&allowLocal, "local", false, "allows local alignment")
 
 	flag.StringVar(&mode, "mode", defaultMode, "(dna|protein|default) alphabet and score table switch")
+	flag.IntVar(&matchValue, "match", 1, "match score for default mode")
+	flag.IntVar(&mismatchValue, "mismatch", -1, "mismatch score for default mode")
+	flag.StringVar(&pairsFile, "pairs", "", "file with score overrides for symbol pairs")
 
 	flag.BoolVar(&pretty, "pretty", false, "enables pretty output mode")
-	flag.IntVar(&lineLength, "line", 100, "line length for default output mode")
+	flag.StringVar(&colorMode, "color", colorAuto, "(auto|always|never) colors in pretty output mode, auto respects NO_COLOR and CLICOLOR_FORCE")
+	flag.StringVar(&colorTheme, "theme", matchTheme, "(match|clustal) color theme for pretty output mode")
+	flag.BoolVar(&matchLine, "match-line", false, "prints '|:.' match line between sequences in default output mode")
+	flag.BoolVar(&showStats, "stats", false, "prints alignment statistics in default output mode")
+	flag.IntVar(&lineLength, "line", 100, "line length for default output mode, in pretty mode defaults to terminal width")
 	flag.StringVar(&outputFile, "out", "", "output file name")
 
 	flag.BoolVar(&startPenalty, "spen", false, "enables start gap penalty")
@@ -60,12 +101,31 @@ func init() {
 
 	flag.BoolVar(&memSave, "mem-save", false, "enables memory save mode")
 
+	flag.BoolVar(&qualityAware, "quality", false, "scales match scores by fastq read quality")
+
+	flag.StringVar(&fastaMode, "fasta-mode", fastaDefaultMode, "(default|strict|lenient) fasta parser mode")
+
+	flag.StringVar(&seq1Spec, "seq1", "", "first sequence as [file][:id|:#index][:start-end]")
+	flag.StringVar(&seq2Spec, "seq2", "", "second sequence as [file][:id|:#index][:start-end]")
+	flag.StringVar(&selectPattern, "select", "", "regexp selecting records by description")
+
+	flag.StringVar(&outputFormat, "format", defaultFormat, "(default|cigar|sam|paf|emboss|blast6|blast7|json|ndjson|html) output format, blast formats accept columns like 'blast6 qseqid sseqid pident'")
+	flag.BoolVar(&extendedCigar, "extended-cigar", false, "uses '=' and 'X' instead of 'M' in cigar")
+
+	flag.BoolVar(&batchMode, "batch", false, "aligns all pairs of selected records")
+
+	flag.StringVar(&dumpFormat, "dump-matrix", "", "(csv|tsv|grid) writes dynamic programming matrices instead of the alignment")
+
 }
 
-// Sequence описывает последовательность из fasta файла
+// Sequence описывает последовательность из fasta, fastq, GenBank или EMBL файла
 type Sequence struct {
 	Description string
 	Value       string
+	// Quality качество прочтения каждого символа в кодировке Phred+33, пусто для fasta
+	Quality string
+	// Features таблица аннотаций из GenBank или EMBL файла
+	Features []Feature
 }
 
 func flagPassed(name string) bool {
@@ -78,9 +138,34 @@ func flagPassed(name string) bool {
 	return found
 }
 
+func buildFastaMode(mode string) FastaMode {
+	switch mode {
+	case fastaStrictMode:
+		return FastaStrict
+	case fastaLenientMode:
+		return FastaLenient
+	}
+
+	return FastaDefault
+}
+
 func main() {
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		fmt.Fprintf(out, "Usage: seq-aligner [options] <file1> [file2]\n")
+		printCommandsUsage(out)
+		fmt.Fprintln(out, "Options:")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
+	if cmd, ok := findCommand(os.Args[1:], flag.Args()); ok {
+		if err := cmd.run(flag.Args()[1:]); err != nil {
+			log.Fatalf("%s: %s", flag.Arg(0), err)
+		}
+		return
+	}
+
 	out := os.Stdout
 	if outputFile != "" {
 		var err error
@@ -91,15 +176,131 @@ func main() {
 		defer out.Close()
 	}
 
-	sequences, err := loadSequences(flag.Args())
+	readerCfg := buildReaderConfig()
+	selectCfg, err := buildSelectConfig()
+	if err != nil {
+		log.Fatalf("can not parse --select: %s", err)
+	}
+	var pairs [][2]*Sequence
+	if batchMode {
+		pairs, err = loadPairs(flag.Args(), selectCfg, readerCfg)
+	} else {
+		var sequences []*Sequence
+		sequences, err = loadSequences(flag.Args(), selectCfg, readerCfg)
+		if err == nil {
+			pairs = [][2]*Sequence{{sequences[0], sequences[1]}}
+		}
+	}
 	if err != nil {
 		log.Fatalf("can not read sequences: %s", err)
 	}
 
-	adapter := buildAdapter(mode)
-	if err := validate(adapter, sequences); err != nil {
+	adapter, aligner, scoring, err := buildScoring()
+	if err != nil {
+		log.Fatalf("can not set up scoring: %s", err)
+	}
+	for _, pair := range pairs {
+		if err := validate(adapter, pair[:]); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	colored, err := useColor(colorMode, out, os.Getenv)
+	if err != nil {
 		log.Fatal(err)
 	}
+	color.NoColor = !colored
+	if dumpFormat != "" {
+		if err := dumpMatrices(out, aligner, pairs, dumpFormat); err != nil {
+			log.Fatalf("can not dump matrices: %s", err)
+		}
+		return
+	}
+	if pretty && !flagPassed("line") {
+		if width := terminalWidth(out); width > 0 {
+			lineLength = MaxInt(width-prettyPrefixWidth-prettySuffixWidth, minPrettyLineLength)
+		}
+	}
+	if lineLength <= 0 {
+		log.Fatalf("line length must be positive, got %d", lineLength)
+	}
+	theme, err := buildOutputTheme(colorTheme, mode, outputFormat, pretty)
+	if err != nil {
+		log.Fatal(err)
+	}
+	formatter, err := buildFormatter(outputFormat, &FormatConfig{
+		Scorer:        adapter,
+		Scoring:       scoring,
+		LineLength:    lineLength,
+		Pretty:        pretty,
+		Theme:         theme,
+		MatchLine:     matchLine,
+		Stats:         showStats,
+		ExtendedCigar: extendedCigar,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	records := make([]*AlignmentRecord, 0, len(pairs))
+	for _, pair := range pairs {
+		var w1, w2 []float64
+		if qualityAware {
+			w1, w2 = pair[0].MatchWeights(), pair[1].MatchWeights()
+		}
+		res, err := aligner.AlignDetailed(pair[0].Value, pair[1].Value, w1, w2)
+		if err != nil {
+			log.Fatalf("can not align %s and %s: %s", recordName(pair[0], "seq1"), recordName(pair[1], "seq2"), err)
+		}
+		records = append(records, &AlignmentRecord{
+			Seq1:        pair[0],
+			Seq2:        pair[1],
+			AlignResult: res,
+		})
+	}
+	if err := formatter.Format(out, records); err != nil {
+		log.Fatalf("can not write alignment: %s", err)
+	}
+}
+
+// buildReaderConfig возвращает параметры чтения записей, заданные флагами
+func buildReaderConfig() *ReaderConfig {
+	return &ReaderConfig{
+		FastaMode: buildFastaMode(fastaMode),
+		Warn: func(err error) {
+			log.Printf("WARN: skipping bad record: %s", err)
+		},
+	}
+}
+
+// buildSelectConfig возвращает параметры выбора записей, заданные флагами
+func buildSelectConfig() (*SelectConfig, error) {
+	selectCfg := &SelectConfig{
+		Seq1: seq1Spec,
+		Seq2: seq2Spec,
+	}
+	if selectPattern != "" {
+		var err error
+		if selectCfg.Pattern, err = regexp.Compile(selectPattern); err != nil {
+			return nil, err
+		}
+	}
+	return selectCfg, nil
+}
+
+// buildScoring возвращает Adapter, Aligner и параметры оценки, заданные флагами
+func buildScoring() (Adapter, Aligner, *ScoringInfo, error) {
+	if mode != defaultMode && (flagPassed("match") || flagPassed("mismatch")) {
+		return nil, nil, nil, fmt.Errorf("--match and --mismatch are only used in %s mode, use --pairs to override %s scores", defaultMode, mode)
+	}
+	adapter := buildAdapter(mode, matchValue, mismatchValue)
+	if pairsFile != "" {
+		var err error
+		adapter, err = loadPairAdapter(adapter, pairsFile)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
 
 	cfg := &SequenceAlignerConfig{
 		AllowLocal:      allowLocal,
@@ -107,27 +308,30 @@ func main() {
 		GapStartPenalty: startPenalty,
 		GapEndPenalty:   endPenalty,
 	}
+	scoring := &ScoringInfo{
+		Mode:            mode,
+		Matrix:          matrixName(mode, matchValue, mismatchValue),
+		Match:           matchValue,
+		Mismatch:        mismatchValue,
+		Pairs:           pairsFile,
+		GapOpen:         gapValue,
+		GapExtend:       gapValue,
+		GapStartPenalty: startPenalty,
+		GapEndPenalty:   endPenalty,
+		QualityWeighted: qualityAware,
+	}
 	var aligner Aligner
 	if memSave {
 		aligner = NewSequenceAlignerMem(cfg, adapter)
 	} else {
 		if flagPassed("gap-extend") {
 			aligner = NewSequenceAlignerExtend(&SequenceAlignerExtendConfig{*cfg, extendGapValue}, adapter)
+			scoring.GapExtend = extendGapValue
 		} else {
 			aligner = NewSequenceAligner(cfg, adapter)
+			// локальное выравнивание поддерживает только SequenceAligner
+			scoring.Local = allowLocal
 		}
 	}
-
-	aligned1, aligned2, score := aligner.Align(sequences[0].Value, sequences[1].Value)
-
-	if pretty && out != os.Stdout {
-		io.WriteString(out, "WARN: can not use '--pretty' with file output!\n")
-		pretty = false
-	}
-	if pretty {
-		WritePretty(out, aligned1, aligned2)
-	} else {
-		WriteAlignedDefault(out, lineLength, aligned1, aligned2)
-	}
-	fmt.Fprintf(out, "Score: %d\n", score)
+	return adapter, aligner, scoring, nil
 }
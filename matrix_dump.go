@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+)
+
+// Ошибки вывода матриц динамического программирования
+var (
+	ErrMatrixTooLarge        = errors.New("sequences are too long to dump matrices")
+	ErrMatrixDumpUnsupported = errors.New("aligner does not build full matrices, disable --mem-save")
+	ErrUnknownDumpFormat     = errors.New("unknown matrix dump format")
+)
+
+// Форматы вывода матриц
+const (
+	dumpCSVFormat  = "csv"
+	dumpTSVFormat  = "tsv"
+	dumpGridFormat = "grid"
+)
+
+// maxDumpLength наибольшая длина последовательности, для которой выводятся матрицы
+const maxDumpLength = 100
+
+// Символы указателей обратного прохода SequenceAligner: откуда получено значение клетки
+const (
+	tracebackDiagonal = '\\'
+	tracebackLeft     = '<'
+	tracebackUp       = '^'
+	tracebackZero     = 'o'
+	tracebackNone     = ' '
+)
+
+// extendTracebackSymbols символы матриц match, insertion и deletion SequenceAlignerExtend,
+// из которой получено значение клетки
+var extendTracebackSymbols = [...]byte{'M', 'I', 'D'}
+
+// DPMatrix матрица динамического программирования выравнивателя.
+// Строки соответствуют символам первой последовательности, столбцы — второй,
+// нулевые строка и столбец — пустым префиксам.
+type DPMatrix struct {
+	Name   string
+	Values [][]int
+	// Traceback символы указателей обратного прохода
+	Traceback [][]byte
+	// Path отмечает клетки пути обратного прохода
+	Path [][]bool
+}
+
+func newDPMatrix(name string, values [][]int, traceback [][]byte) *DPMatrix {
+	path := make([][]bool, len(values))
+	for i := range path {
+		path[i] = make([]bool, len(values[i]))
+	}
+	return &DPMatrix{Name: name, Values: values, Traceback: traceback, Path: path}
+}
+
+// MatrixDump матрицы, построенные выравнивателем для пары последовательностей
+type MatrixDump struct {
+	Seq1     []rune
+	Seq2     []rune
+	Matrices []*DPMatrix
+}
+
+// MatrixDumper выравниватель, умеющий возвращать построенные матрицы
+type MatrixDumper interface {
+	DumpMatrices(str1, str2 string) *MatrixDump
+}
+
+// linearTraceback переводит действия SequenceAligner в символы указателей
+func linearTraceback(actions [][]action) [][]byte {
+	res := make([][]byte, len(actions))
+	for i := range actions {
+		res[i] = make([]byte, len(actions[i]))
+		for j, act := range actions[i] {
+			switch {
+			case i == 0 && j == 0:
+				res[i][j] = tracebackNone
+			case act == letterAction:
+				res[i][j] = tracebackDiagonal
+			case act == firstGapAction:
+				res[i][j] = tracebackLeft
+			case act == secondGapAction:
+				res[i][j] = tracebackUp
+			default:
+				res[i][j] = tracebackZero
+			}
+		}
+	}
+	return res
+}
+
+// extendTraceback извлекает из упакованных действий SequenceAlignerExtend указатели матрицы с номером k
+func extendTraceback(actions [][]byte, k uint) [][]byte {
+	res := make([][]byte, len(actions))
+	for i := range actions {
+		res[i] = make([]byte, len(actions[i]))
+		for j, packed := range actions[i] {
+			if i == 0 && j == 0 {
+				res[i][j] = tracebackNone
+				continue
+			}
+			res[i][j] = extendTracebackSymbols[(packed>>(k*2))&0b11]
+		}
+	}
+	return res
+}
+
+// markPath отмечает клетки пути выравнивания res. Если матриц несколько, то клетка отмечается
+// в матрице match, insertion или deletion в зависимости от операции, которой в неё пришли.
+func (d *MatrixDump) markPath(res *AlignResult) {
+	runes1, runes2 := []rune(res.Aligned1), []rune(res.Aligned2)
+	i, j := res.Start1, res.Start2
+	d.Matrices[0].Path[i][j] = true
+	for k := range runes1 {
+		matrix := 0
+		switch {
+		case runes1[k] == gapRune:
+			matrix = 1
+			j++
+		case runes2[k] == gapRune:
+			matrix = 2
+			i++
+		default:
+			i++
+			j++
+		}
+		if len(d.Matrices) == 1 {
+			matrix = 0
+		}
+		d.Matrices[matrix].Path[i][j] = true
+	}
+}
+
+// WriteDelimited выводит матрицы таблицами с разделителем sep: для каждой матрицы таблицу значений
+// и таблицу указателей обратного прохода, в которой клетки пути отмечены '*'.
+// Первая строка таблицы содержит её название и символы второй последовательности.
+func (d *MatrixDump) WriteDelimited(w io.Writer, sep string) error {
+	bw := bufio.NewWriter(w)
+	writeHeader := func(title string) {
+		cells := []string{title, ""}
+		for _, r := range d.Seq2 {
+			cells = append(cells, delimitedCell(string(r), sep))
+		}
+		fmt.Fprintln(bw, strings.Join(cells, sep))
+	}
+	rowName := func(i int) string {
+		if i == 0 {
+			return ""
+		}
+		return delimitedCell(string(d.Seq1[i-1]), sep)
+	}
+
+	for k, m := range d.Matrices {
+		if k > 0 {
+			fmt.Fprintln(bw)
+		}
+		writeHeader(m.Name)
+		for i, row := range m.Values {
+			cells := []string{rowName(i)}
+			for _, v := range row {
+				cells = append(cells, strconv.Itoa(v))
+			}
+			fmt.Fprintln(bw, strings.Join(cells, sep))
+		}
+
+		fmt.Fprintln(bw)
+		writeHeader(m.Name + " traceback")
+		for i, row := range m.Traceback {
+			cells := []string{rowName(i)}
+			for j, symbol := range row {
+				cell := strings.TrimSpace(string(symbol))
+				if m.Path[i][j] {
+					cell += "*"
+				}
+				cells = append(cells, delimitedCell(cell, sep))
+			}
+			fmt.Fprintln(bw, strings.Join(cells, sep))
+		}
+	}
+	return bw.Flush()
+}
+
+// delimitedCell заключает в кавычки ячейку, содержащую разделитель, кавычку или '\', как в CSV
+func delimitedCell(s, sep string) string {
+	if strings.ContainsAny(s, sep+`"\`) {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return s
+}
+
+// WriteGrid выводит матрицы сеткой для терминала: каждая клетка содержит значение и указатель
+// обратного прохода, клетки пути отмечены '*' и, если цвета включены, выделены цветом.
+func (d *MatrixDump) WriteGrid(w io.Writer) error {
+	highlight := color.New(color.FgBlack, color.BgYellow)
+	bw := bufio.NewWriter(w)
+	for k, m := range d.Matrices {
+		if k > 0 {
+			fmt.Fprintln(bw)
+		}
+		width := 1
+		for _, row := range m.Values {
+			for _, v := range row {
+				width = MaxInt(width, len(strconv.Itoa(v)))
+			}
+		}
+		// клетка: значение, указатель и отметка пути
+		cellWidth := width + 2
+
+		fmt.Fprintf(bw, "%s:\n", m.Name)
+		// символ второй последовательности стоит над последней цифрой значения
+		fmt.Fprintf(bw, "  %*s", cellWidth+1, "")
+		for _, r := range d.Seq2 {
+			fmt.Fprintf(bw, " %*s  ", width, string(r))
+		}
+		fmt.Fprintln(bw)
+
+		for i, row := range m.Values {
+			name := ' '
+			if i > 0 {
+				name = d.Seq1[i-1]
+			}
+			fmt.Fprintf(bw, "%c ", name)
+			for j, v := range row {
+				mark := ' '
+				if m.Path[i][j] {
+					mark = '*'
+				}
+				cell := fmt.Sprintf("%*d%c%c", width, v, m.Traceback[i][j], mark)
+				bw.WriteByte(' ')
+				if m.Path[i][j] {
+					highlight.Fprint(bw, cell)
+				} else {
+					bw.WriteString(cell)
+				}
+			}
+			fmt.Fprintln(bw)
+		}
+	}
+	return bw.Flush()
+}
+
+// dumpMatrices выводит в формате format матрицы, которые aligner строит для каждой пары pairs
+func dumpMatrices(w io.Writer, aligner Aligner, pairs [][2]*Sequence, format string) error {
+	dumper, ok := aligner.(MatrixDumper)
+	if !ok {
+		return ErrMatrixDumpUnsupported
+	}
+	switch format {
+	case dumpCSVFormat, dumpTSVFormat, dumpGridFormat:
+	default:
+		return errors.Wrap(ErrUnknownDumpFormat, format)
+	}
+	for _, pair := range pairs {
+		len1, len2 := len([]rune(pair[0].Value)), len([]rune(pair[1].Value))
+		if MaxInt(len1, len2) > maxDumpLength {
+			return errors.Wrapf(ErrMatrixTooLarge, "%d and %d symbols, limit is %d", len1, len2, maxDumpLength)
+		}
+	}
+
+	for k, pair := range pairs {
+		if len(pairs) > 1 {
+			if k > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "# %s vs %s\n", recordName(pair[0], "seq1"), recordName(pair[1], "seq2"))
+		}
+		dump := dumper.DumpMatrices(pair[0].Value, pair[1].Value)
+
+		var err error
+		switch format {
+		case dumpCSVFormat:
+			err = dump.WriteDelimited(w, ",")
+		case dumpTSVFormat:
+			err = dump.WriteDelimited(w, "\t")
+		default:
+			err = dump.WriteGrid(w)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
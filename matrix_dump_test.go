@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type MatrixDumpTestSuite struct {
+	suite.Suite
+}
+
+func (s *MatrixDumpTestSuite) TestLinear() {
+	aligner := NewSequenceAligner(&SequenceAlignerConfig{
+		GapPenalty:      -2,
+		GapStartPenalty: true,
+		GapEndPenalty:   true,
+	}, NewDefaultAdapter(1, -1))
+
+	dump := aligner.DumpMatrices("AC", "AC")
+	s.Require().Len(dump.Matrices, 1)
+	m := dump.Matrices[0]
+	s.Equal("dp", m.Name)
+	s.Equal([][]int{{0, -2, -4}, {-2, 1, -1}, {-4, -1, 2}}, m.Values)
+	s.Equal([][]byte{[]byte(` <<`), []byte(`^\<`), []byte(`^^\`)}, m.Traceback)
+	s.Equal([][]bool{{true, false, false}, {false, true, false}, {false, false, true}}, m.Path)
+
+	// после вывода матриц выравниватель работает как обычно
+	s.Nil(aligner.dump)
+}
+
+func (s *MatrixDumpTestSuite) TestLocalPath() {
+	aligner := NewSequenceAligner(&SequenceAlignerConfig{GapPenalty: -2, AllowLocal: true}, NewDefaultAdapter(1, -1))
+
+	dump := aligner.DumpMatrices("TTAC", "AC")
+	m := dump.Matrices[0]
+	s.Len(m.Values, 5)
+	s.True(m.Path[2][0])
+	s.True(m.Path[3][1])
+	s.True(m.Path[4][2])
+	s.Equal(byte('o'), m.Traceback[1][1])
+}
+
+func (s *MatrixDumpTestSuite) TestExtend() {
+	aligner := NewSequenceAlignerExtend(&SequenceAlignerExtendConfig{
+		SequenceAlignerConfig: SequenceAlignerConfig{GapPenalty: -3, GapStartPenalty: true, GapEndPenalty: true},
+		ExtendGapPenalty:      -1,
+	}, NewDefaultAdapter(2, -1))
+
+	dump := aligner.DumpMatrices("ACGGT", "ACT")
+	s.Require().Len(dump.Matrices, 3)
+	s.Equal("match", dump.Matrices[0].Name)
+	s.Equal("insertion", dump.Matrices[1].Name)
+	s.Equal("deletion", dump.Matrices[2].Name)
+
+	// AC--T выравнивается как AC, две deletion и T
+	s.True(dump.Matrices[0].Path[0][0])
+	s.True(dump.Matrices[0].Path[2][2])
+	s.True(dump.Matrices[2].Path[3][2])
+	s.True(dump.Matrices[2].Path[4][2])
+	s.True(dump.Matrices[0].Path[5][3])
+	s.Equal(byte('D'), dump.Matrices[0].Traceback[5][3])
+	s.Equal(byte('M'), dump.Matrices[2].Traceback[3][2])
+	s.Equal(byte('D'), dump.Matrices[2].Traceback[4][2])
+
+	marked := 0
+	for _, m := range dump.Matrices {
+		for _, row := range m.Path {
+			for _, cell := range row {
+				if cell {
+					marked++
+				}
+			}
+		}
+	}
+	s.Equal(6, marked)
+}
+
+func (s *MatrixDumpTestSuite) TestWriteDelimited() {
+	aligner := NewSequenceAligner(&SequenceAlignerConfig{
+		GapPenalty:      -2,
+		GapStartPenalty: true,
+		GapEndPenalty:   true,
+	}, NewDefaultAdapter(1, -1))
+	dump := aligner.DumpMatrices("AC", "AC")
+
+	buf := &bytes.Buffer{}
+	s.Require().NoError(dump.WriteDelimited(buf, ","))
+	s.Equal(strings.Join([]string{
+		"dp,,A,C",
+		",0,-2,-4",
+		"A,-2,1,-1",
+		"C,-4,-1,2",
+		"",
+		"dp traceback,,A,C",
+		",*,<,<",
+		`A,^,"\*",<`,
+		`C,^,^,"\*"`,
+		"",
+	}, "\n"), buf.String())
+
+	buf.Reset()
+	s.Require().NoError(dump.WriteDelimited(buf, "\t"))
+	s.Contains(buf.String(), "A\t-2\t1\t-1\n")
+}
+
+func (s *MatrixDumpTestSuite) TestWriteGrid() {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	aligner := NewSequenceAligner(&SequenceAlignerConfig{
+		GapPenalty:      -2,
+		GapStartPenalty: true,
+		GapEndPenalty:   true,
+	}, NewDefaultAdapter(1, -1))
+	dump := aligner.DumpMatrices("AC", "AC")
+
+	buf := &bytes.Buffer{}
+	s.Require().NoError(dump.WriteGrid(buf))
+	s.Equal(strings.Join([]string{
+		"dp:",
+		"         A    C  ",
+		"    0 * -2<  -4< ",
+		"A  -2^   1\\* -1< ",
+		"C  -4^  -1^   2\\*",
+		"",
+	}, "\n"), buf.String())
+}
+
+func (s *MatrixDumpTestSuite) TestDumpMatricesErrors() {
+	scorer := NewDefaultAdapter(1, -1)
+	cfg := &SequenceAlignerConfig{GapPenalty: -2}
+	short := [][2]*Sequence{{{Value: "AC"}, {Value: "AC"}}}
+
+	err := dumpMatrices(&bytes.Buffer{}, NewSequenceAlignerMem(cfg, scorer), short, dumpCSVFormat)
+	s.True(errors.Is(err, ErrMatrixDumpUnsupported))
+
+	err = dumpMatrices(&bytes.Buffer{}, NewSequenceAligner(cfg, scorer), short, "xml")
+	s.True(errors.Is(err, ErrUnknownDumpFormat))
+
+	long := [][2]*Sequence{{{Value: "AC"}, {Value: strings.Repeat("A", maxDumpLength+1)}}}
+	err = dumpMatrices(&bytes.Buffer{}, NewSequenceAligner(cfg, scorer), long, dumpCSVFormat)
+	s.True(errors.Is(err, ErrMatrixTooLarge))
+}
+
+func (s *MatrixDumpTestSuite) TestDumpMatricesBatch() {
+	scorer := NewDefaultAdapter(1, -1)
+	aligner := NewSequenceAligner(&SequenceAlignerConfig{GapPenalty: -2}, scorer)
+	pairs := [][2]*Sequence{
+		{{Description: "a", Value: "AC"}, {Description: "b", Value: "A"}},
+		{{Description: "a", Value: "AC"}, {Description: "c", Value: "C"}},
+	}
+
+	buf := &bytes.Buffer{}
+	s.Require().NoError(dumpMatrices(buf, aligner, pairs, dumpTSVFormat))
+	s.True(strings.HasPrefix(buf.String(), "# a vs b\n"))
+	s.Contains(buf.String(), "\n\n# a vs c\n")
+}
+
+func TestMatrixDumpSuite(t *testing.T) {
+	suite.Run(t, new(MatrixDumpTestSuite))
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// pafFormatter выводит выравнивания в формате PAF, совместимом с minimap2.
+// Запросом считается вторая последовательность, целью — первая. Невыровненные пары не выводятся.
+type pafFormatter struct {
+	cfg *FormatConfig
+}
+
+func (f *pafFormatter) Format(w io.Writer, records []*AlignmentRecord) error {
+	bw := bufio.NewWriter(w)
+	for _, rec := range records {
+		res := rec.trimGaps()
+		if res.Aligned1 == "" {
+			continue
+		}
+
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%d\t+\t%s\t%d\t%d\t%d\t%d\t%d\t%d\tcg:Z:%s\tAS:i:%d\tNM:i:%d\n",
+			recordName(rec.Seq2, "seq2"), res.Len2, res.Start2, res.End2,
+			recordName(rec.Seq1, "seq1"), res.Len1, res.Start1, res.End1,
+			res.matches(), len([]rune(res.Aligned1)), samMapqUnavailable,
+			NewCigar(res, f.cfg.ExtendedCigar).unclipped(), res.Score, res.editDistance())
+	}
+	return bw.Flush()
+}
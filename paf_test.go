@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PafTestSuite struct {
+	suite.Suite
+}
+
+func (s *PafTestSuite) TestFormat() {
+	target := &Sequence{Description: "target", Value: "TTTACGTACGTTT"}
+	query := &Sequence{Description: "query", Value: "GGACGACGTGG"}
+	aligner := NewSequenceAligner(&SequenceAlignerConfig{GapPenalty: -1, AllowLocal: true}, NewDefaultAdapter(1, -1))
+
+	var buf bytes.Buffer
+	err := (&pafFormatter{cfg: &FormatConfig{}}).Format(&buf, []*AlignmentRecord{
+		{
+			Seq1:        target,
+			Seq2:        query,
+			AlignResult: alignDetailed(aligner, target.Value, query.Value),
+		},
+		{
+			Seq1:        target,
+			Seq2:        &Sequence{Description: "unaligned"},
+			AlignResult: &AlignResult{Len1: 13},
+		},
+	})
+	s.Require().NoError(err)
+	s.Equal("query\t11\t2\t9\t+\ttarget\t13\t3\t11\t7\t8\t255\tcg:Z:3M1D4M\tAS:i:6\tNM:i:1\n", buf.String())
+}
+
+func TestPafSuite(t *testing.T) {
+	suite.Run(t, new(PafTestSuite))
+}
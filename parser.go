@@ -2,78 +2,341 @@ package main
 
 import (
 	"bufio"
+	"fmt"
 	"io"
 	"strings"
-	"unicode"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
 )
 
 // Possible parse errors
 var (
-	ErrBadHeader = errors.New("fasta parser: bad header")
+	ErrBadHeader        = errors.New("fasta parser: bad header")
+	ErrEmptyRecord      = errors.New("fasta parser: empty record")
+	ErrNonPrintable     = errors.New("fasta parser: non-printable byte")
+	ErrUnexpectedSpace  = errors.New("fasta parser: whitespace inside sequence")
+	ErrMixedLineEndings = errors.New("fasta parser: mixed LF and CRLF line endings")
+	ErrUnknownFormat    = errors.New("unknown sequence file format")
 )
 
+// sniffWindow is the number of bytes inspected to detect input format
+const sniffWindow = 4096
+
+// ParseError is a parse error with its location in the input
+type ParseError struct {
+	// Record is 1-based index of the record
+	Record int
+	// Line and Column are 1-based position of the error, Column counts characters, not bytes
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s (record %d, line %d, column %d)", e.Err, e.Record, e.Line, e.Column)
+}
+
+// Cause returns the underlying error
+func (e *ParseError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// FastaMode controls how FastaParser treats malformed input
+type FastaMode int
+
+// Possible FASTA parser modes
+const (
+	// FastaDefault accepts empty records and drops whitespace inside sequences
+	FastaDefault FastaMode = iota
+	// FastaStrict rejects empty records, mixed line endings, whitespace inside sequences and non-printable bytes
+	FastaStrict
+	// FastaLenient checks records as FastaStrict, but skips bad records with a warning
+	FastaLenient
+)
+
+// ReaderConfig contains options of record readers
+type ReaderConfig struct {
+	FastaMode FastaMode
+	// Warn is called for every record skipped in FastaLenient mode
+	Warn func(err error)
+}
+
+// RecordReader reads sequence records one by one
+type RecordReader interface {
+	// Next gets next record.
+	// Returns io.EOF if all records were read.
+	Next() (*Sequence, error)
+}
+
+// NewRecordReader returns RecordReader for the format of r content.
+// The format is detected by the beginning of the first non-empty line:
+// '>' for FASTA, '@' for FASTQ, "LOCUS" for GenBank and "ID" for EMBL.
+// FASTA comment lines starting with ';' are skipped. cfg may be nil.
+func NewRecordReader(r io.Reader, cfg *ReaderConfig) (RecordReader, error) {
+	if cfg == nil {
+		cfg = &ReaderConfig{}
+	}
+
+	reader := bufio.NewReaderSize(r, sniffWindow)
+	window, err := reader.Peek(sniffWindow)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(window), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == ';' {
+			continue
+		}
+
+		switch {
+		case line[0] == '>':
+			return NewFastaParserMode(reader, cfg.FastaMode, cfg.Warn), nil
+		case line[0] == '@':
+			return NewFastqParser(reader), nil
+		case strings.HasPrefix(line, "LOCUS"):
+			return NewGenBankParser(reader), nil
+		case strings.HasPrefix(line, "ID   "):
+			return NewEMBLParser(reader), nil
+		}
+		return nil, ErrUnknownFormat
+	}
+
+	if len(window) < sniffWindow {
+		return nil, io.EOF
+	}
+	return nil, ErrUnknownFormat
+}
+
+// fastaLine is a line of FASTA input
+type fastaLine struct {
+	text   string
+	ending string
+	number int
+}
+
 // FastaParser parses a sequence of objects from reader
 type FastaParser struct {
 	reader *bufio.Reader
+	mode   FastaMode
+	warn   func(err error)
+	// scanner reads records in FastaDefault mode, which needs no per-line checks
+	scanner *FastaScanner
+
+	// line is the number of lines read
+	line int
+	// record is the number of records started
+	record int
+	// pending is the header line of the next record read ahead
+	pending *fastaLine
+	// ending is the line ending of the first line, used in strict checks
+	ending string
 }
 
-// NewFastaParser returns new FastaParser
+// NewFastaParser returns new FastaParser in FastaDefault mode
 func NewFastaParser(r io.Reader) *FastaParser {
+	return NewFastaParserMode(r, FastaDefault, nil)
+}
+
+// NewFastaParserMode returns new FastaParser in the given mode.
+// warn is called for every skipped record in FastaLenient mode and may be nil.
+func NewFastaParserMode(r io.Reader, mode FastaMode, warn func(err error)) *FastaParser {
+	if mode == FastaDefault {
+		return &FastaParser{
+			mode:    mode,
+			scanner: NewFastaScanner(r),
+		}
+	}
 	return &FastaParser{
 		reader: bufio.NewReader(r),
+		mode:   mode,
+		warn:   warn,
 	}
 }
 
 // Next gets next object from reader.
+// Only '>' at the beginning of a line starts a new object, lines starting with ';' are comments.
 // Returns io.EOF if all objects were read.
 func (p *FastaParser) Next() (*Sequence, error) {
-	header, err := p.reader.ReadString('\n')
+	if p.scanner != nil {
+		if !p.scanner.Scan() {
+			if err := p.scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		return p.scanner.Sequence(), nil
+	}
+
+	for {
+		seq, err := p.next()
+		if err == nil || p.mode != FastaLenient {
+			return seq, err
+		}
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			return nil, err
+		}
+		if p.warn != nil {
+			p.warn(err)
+		}
+		if err := p.skipRecord(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *FastaParser) next() (*Sequence, error) {
+	header, err := p.nextLine()
 	if err != nil {
 		return nil, err
 	}
 
-	descr, err := p.parseHeader(header)
-	if err != nil {
+	p.record++
+	if header.text[0] != '>' {
+		return nil, p.errorAt(header, 1, ErrBadHeader)
+	}
+	if err := p.checkLine(header); err != nil {
 		return nil, err
 	}
 
 	valueBuilder := &strings.Builder{}
 	for {
-		b, err := p.reader.ReadByte()
+		line, err := p.nextLine()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			// end of file
-			if err == io.EOF {
-				break
-			}
 			return nil, err
 		}
 		// end of current object
-		if b == '>' {
-			if err := p.reader.UnreadByte(); err != nil {
-				return nil, err
-			}
+		if line.text[0] == '>' {
+			p.pending = line
 			break
 		}
-		// ignore newline
-		if unicode.IsSpace(rune(b)) {
-			continue
+
+		if err := p.checkLine(line); err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(line.text); i++ {
+			if isSequenceSpace(line.text[i]) {
+				if p.mode != FastaDefault {
+					return nil, p.errorAt(line, column(line.text, i), ErrUnexpectedSpace)
+				}
+				continue
+			}
+			valueBuilder.WriteByte(line.text[i])
 		}
+	}
 
-		valueBuilder.WriteByte(b)
+	if valueBuilder.Len() == 0 && p.mode != FastaDefault {
+		return nil, p.errorAt(header, 1, ErrEmptyRecord)
 	}
 
 	return &Sequence{
-		Description: descr,
+		Description: strings.TrimSpace(header.text[1:]),
 		Value:       valueBuilder.String(),
 	}, nil
 }
 
-func (p *FastaParser) parseHeader(h string) (string, error) {
-	if len(h) == 0 || h[0] != '>' {
-		return "", ErrBadHeader
+// nextLine returns next non-empty line which is not a comment.
+func (p *FastaParser) nextLine() (*fastaLine, error) {
+	if p.pending != nil {
+		line := p.pending
+		p.pending = nil
+		return line, nil
 	}
 
-	return strings.TrimSpace(h[1:]), nil
+	for {
+		raw, err := p.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || raw == "") {
+			return nil, err
+		}
+		p.line++
+
+		line := &fastaLine{number: p.line}
+		line.text = strings.TrimSuffix(raw, "\n")
+		line.ending = raw[len(line.text):]
+		if strings.HasSuffix(line.text, "\r") {
+			line.text = line.text[:len(line.text)-1]
+			line.ending = "\r" + line.ending
+		}
+		if p.ending == "" {
+			p.ending = line.ending
+		}
+
+		if strings.TrimSpace(line.text) == "" || line.text[0] == ';' {
+			continue
+		}
+		return line, nil
+	}
+}
+
+// checkLine checks line endings and non-printable bytes in strict modes.
+// Tabs are not reported here: they are allowed in headers
+// and reported as ErrUnexpectedSpace in sequence lines.
+func (p *FastaParser) checkLine(line *fastaLine) error {
+	if p.mode == FastaDefault {
+		return nil
+	}
+
+	// the last line may have no ending at all
+	if line.ending != "" && line.ending != p.ending {
+		return p.errorAt(line, column(line.text, len(line.text)), ErrMixedLineEndings)
+	}
+	for i := 0; i < len(line.text); i++ {
+		b := line.text[i]
+		if b < ' ' && b != '\t' || b == 0x7f {
+			return p.errorAt(line, column(line.text, i), errors.Wrapf(ErrNonPrintable, "0x%02x", b))
+		}
+	}
+	return nil
+}
+
+// skipRecord skips lines until the next header
+func (p *FastaParser) skipRecord() error {
+	for p.pending == nil {
+		line, err := p.nextLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if line.text[0] == '>' {
+			p.pending = line
+		}
+	}
+	return nil
+}
+
+// column returns 1-based column of the i-th byte of text counted in characters
+func column(text string, i int) int {
+	return utf8.RuneCountInString(text[:i]) + 1
+}
+
+func (p *FastaParser) errorAt(line *fastaLine, column int, err error) error {
+	return &ParseError{
+		Record: p.record,
+		Line:   line.number,
+		Column: column,
+		Err:    err,
+	}
+}
+
+// readLine returns next line from r without line ending.
+// Returns io.EOF only if there are no more bytes.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Possible EMBL parse errors
+var (
+	ErrBadEMBLHeader = errors.New("embl parser: record does not start with ID")
+)
+
+// emblPrefixLength is the length of EMBL line type code with following spaces
+const emblPrefixLength = 5
+
+// EMBLParser parses a sequence of EMBL flat file records from reader
+type EMBLParser struct {
+	flatFileReader
+}
+
+// NewEMBLParser returns new EMBLParser
+func NewEMBLParser(r io.Reader) *EMBLParser {
+	return &EMBLParser{
+		flatFileReader: flatFileReader{reader: bufio.NewReader(r)},
+	}
+}
+
+// Next gets next record from reader.
+// Description is the ID name followed by the DE lines,
+// sequence is taken from the SQ block in upper case.
+// A record not terminated with "//" is reported as ParseError.
+// Returns io.EOF if all records were read.
+func (p *EMBLParser) Next() (*Sequence, error) {
+	line, err := p.nextRecord()
+	if err != nil {
+		return nil, err
+	}
+	if lineCode(line) != "ID" {
+		return nil, ErrBadEMBLHeader
+	}
+
+	var name string
+	if fields := strings.Fields(lineContent(line)); len(fields) > 0 {
+		name = strings.TrimSuffix(fields[0], ";")
+	}
+
+	definition := &strings.Builder{}
+	features := &featureTableBuilder{}
+	valueBuilder := &strings.Builder{}
+	inSequence := false
+	for {
+		line, err := p.readLine()
+		if err == io.EOF {
+			return nil, p.truncated()
+		}
+		if line == recordEnd {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// sequence lines have no line type code
+		if inSequence {
+			writeSequenceLine(valueBuilder, line)
+			continue
+		}
+
+		switch lineCode(line) {
+		case "DE":
+			if definition.Len() > 0 {
+				definition.WriteByte(' ')
+			}
+			definition.WriteString(strings.TrimSpace(lineContent(line)))
+		case "FT":
+			// the feature table layout matches GenBank after the line type code
+			features.addLine(strings.Repeat(" ", emblPrefixLength) + lineContent(line))
+		case "SQ":
+			inSequence = true
+		}
+	}
+
+	return &Sequence{
+		Description: joinDescription(name, definition.String()),
+		Value:       valueBuilder.String(),
+		Features:    features.build(),
+	}, nil
+}
+
+// lineCode returns two letter EMBL line type code
+func lineCode(line string) string {
+	if len(line) < 2 {
+		return ""
+	}
+	return line[:2]
+}
+
+// lineContent returns EMBL line without line type code
+func lineContent(line string) string {
+	if len(line) < emblPrefixLength {
+		return ""
+	}
+	return line[emblPrefixLength:]
+}
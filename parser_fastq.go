@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Possible FASTQ parse errors
+var (
+	ErrBadFastqHeader     = errors.New("fastq parser: bad header")
+	ErrBadFastqQuality    = errors.New("fastq parser: quality length does not match sequence length")
+	ErrUnexpectedFastqEOF = errors.New("fastq parser: unexpected end of file")
+)
+
+// FastqParser parses a sequence of FASTQ records from reader
+type FastqParser struct {
+	reader *bufio.Reader
+}
+
+// NewFastqParser returns new FastqParser
+func NewFastqParser(r io.Reader) *FastqParser {
+	return &FastqParser{
+		reader: bufio.NewReader(r),
+	}
+}
+
+// Next gets next record from reader.
+// Returns io.EOF if all records were read.
+func (p *FastqParser) Next() (*Sequence, error) {
+	header, err := p.readHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	// sequence may be wrapped, it ends with '+' separator line
+	valueBuilder := &strings.Builder{}
+	for {
+		line, err := readLine(p.reader)
+		if err == io.EOF {
+			return nil, ErrUnexpectedFastqEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "+") {
+			break
+		}
+		valueBuilder.WriteString(line)
+	}
+
+	// quality may be wrapped too and may start with '@',
+	// so it is read until its length reaches the sequence length
+	qualityBuilder := &strings.Builder{}
+	for qualityBuilder.Len() < valueBuilder.Len() {
+		line, err := readLine(p.reader)
+		if err == io.EOF {
+			return nil, ErrUnexpectedFastqEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		qualityBuilder.WriteString(line)
+	}
+	if qualityBuilder.Len() != valueBuilder.Len() {
+		return nil, ErrBadFastqQuality
+	}
+
+	return &Sequence{
+		Description: header,
+		Value:       valueBuilder.String(),
+		Quality:     qualityBuilder.String(),
+	}, nil
+}
+
+// readHeader skips empty lines and returns description from the '@' header line
+func (p *FastqParser) readHeader() (string, error) {
+	for {
+		line, err := readLine(p.reader)
+		if err != nil {
+			return "", err
+		}
+		if line == "" {
+			continue
+		}
+		if line[0] != '@' {
+			return "", ErrBadFastqHeader
+		}
+		return strings.TrimSpace(line[1:]), nil
+	}
+}
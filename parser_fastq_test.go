@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type FastqParserTestSuite struct {
+	suite.Suite
+}
+
+func (s *FastqParserTestSuite) TestNext() {
+	parser := NewFastqParser(strings.NewReader(
+		"@read1 first\n" +
+			"ACGT\n" +
+			"+\n" +
+			"II#I\n" +
+			"\n" +
+			// перенос строк и качество, начинающееся с '@'
+			"@read2\r\n" +
+			"AC\r\n" +
+			"GT\r\n" +
+			"+read2\r\n" +
+			"@@\r\n" +
+			"II",
+	))
+
+	seq, err := parser.Next()
+	s.Require().NoError(err)
+	s.Equal(&Sequence{Description: "read1 first", Value: "ACGT", Quality: "II#I"}, seq)
+
+	seq, err = parser.Next()
+	s.Require().NoError(err)
+	s.Equal(&Sequence{Description: "read2", Value: "ACGT", Quality: "@@II"}, seq)
+
+	_, err = parser.Next()
+	s.Equal(io.EOF, err)
+}
+
+func (s *FastqParserTestSuite) TestNextErrors() {
+	for _, c := range []struct {
+		data string
+		err  error
+	}{
+		{data: "read\nACGT\n+\nIIII\n", err: ErrBadFastqHeader},
+		{data: "@read\nACGT\n", err: ErrUnexpectedFastqEOF},
+		{data: "@read\nACGT\n+\nII", err: ErrUnexpectedFastqEOF},
+		{data: "@read\nACGT\n+\nIII\nIII\n", err: ErrBadFastqQuality},
+	} {
+		_, err := NewFastqParser(strings.NewReader(c.data)).Next()
+		s.Equal(c.err, err, c.data)
+	}
+}
+
+func (s *FastqParserTestSuite) TestNewRecordReader() {
+	reader, err := NewRecordReader(strings.NewReader("\n>seq\nACGT\n"), nil)
+	s.Require().NoError(err)
+	s.IsType(&FastaParser{}, reader)
+
+	reader, err = NewRecordReader(strings.NewReader("@seq\nACGT\n+\nIIII\n"), nil)
+	s.Require().NoError(err)
+	s.IsType(&FastqParser{}, reader)
+
+	_, err = NewRecordReader(strings.NewReader("ACGT\n"), nil)
+	s.Equal(ErrUnknownFormat, err)
+}
+
+func (s *FastqParserTestSuite) TestMatchWeights() {
+	seq := &Sequence{Value: "ACG", Quality: "+5!"}
+	weights := seq.MatchWeights()
+	s.InDeltaSlice([]float64{0.9, 0.99, 0}, weights, 1e-9)
+
+	s.Nil((&Sequence{Value: "ACG"}).MatchWeights())
+}
+
+func TestFastqParserSuite(t *testing.T) {
+	suite.Run(t, new(FastqParserTestSuite))
+}
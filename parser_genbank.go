@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Possible GenBank and EMBL parse errors
+var (
+	ErrBadGenBankHeader = errors.New("genbank parser: record does not start with LOCUS")
+	ErrTruncatedRecord  = errors.New("flat file parser: record is not terminated with //")
+)
+
+// recordEnd terminates GenBank and EMBL records
+const recordEnd = "//"
+
+// flatFileReader reads lines of GenBank and EMBL files counting lines and records
+type flatFileReader struct {
+	reader *bufio.Reader
+	// line is the number of lines read
+	line int
+	// record is the number of records started
+	record int
+	// start is the line number of the current record header
+	start int
+}
+
+// readLine returns next line without line ending.
+// Returns io.EOF only if there are no more bytes.
+func (r *flatFileReader) readLine() (string, error) {
+	line, err := readLine(r.reader)
+	if err == nil {
+		r.line++
+	}
+	return line, err
+}
+
+// nextRecord skips empty lines and returns the header line of the next record
+func (r *flatFileReader) nextRecord() (string, error) {
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(line) != "" {
+			r.record++
+			r.start = r.line
+			return line, nil
+		}
+	}
+}
+
+// truncated returns an error for the current record cut off at the end of input
+func (r *flatFileReader) truncated() error {
+	return &ParseError{Record: r.record, Line: r.start, Column: 1, Err: ErrTruncatedRecord}
+}
+
+// GenBankParser parses a sequence of GenBank flat file records from reader
+type GenBankParser struct {
+	flatFileReader
+}
+
+// NewGenBankParser returns new GenBankParser
+func NewGenBankParser(r io.Reader) *GenBankParser {
+	return &GenBankParser{
+		flatFileReader: flatFileReader{reader: bufio.NewReader(r)},
+	}
+}
+
+// Next gets next record from reader.
+// Description is the LOCUS name followed by the DEFINITION,
+// sequence is taken from the ORIGIN block in upper case.
+// A record not terminated with "//" is reported as ParseError.
+// Returns io.EOF if all records were read.
+func (p *GenBankParser) Next() (*Sequence, error) {
+	line, err := p.nextRecord()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "LOCUS") {
+		return nil, ErrBadGenBankHeader
+	}
+
+	var name string
+	if fields := strings.Fields(line[len("LOCUS"):]); len(fields) > 0 {
+		name = fields[0]
+	}
+
+	definition := &strings.Builder{}
+	features := &featureTableBuilder{}
+	valueBuilder := &strings.Builder{}
+	// section is the current top level keyword, continuation lines start with spaces
+	section := "LOCUS"
+	for {
+		line, err := p.readLine()
+		if err == io.EOF {
+			return nil, p.truncated()
+		}
+		if line == recordEnd {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if line != "" && !unicode.IsSpace(rune(line[0])) {
+			section = strings.Fields(line)[0]
+			if section == "DEFINITION" {
+				definition.WriteString(strings.TrimSpace(line[len(section):]))
+			}
+			continue
+		}
+
+		switch section {
+		case "DEFINITION":
+			definition.WriteByte(' ')
+			definition.WriteString(strings.TrimSpace(line))
+		case "FEATURES":
+			features.addLine(line)
+		case "ORIGIN":
+			writeSequenceLine(valueBuilder, line)
+		}
+	}
+
+	return &Sequence{
+		Description: joinDescription(name, definition.String()),
+		Value:       valueBuilder.String(),
+		Features:    features.build(),
+	}, nil
+}
+
+// writeSequenceLine writes residues of a sequence block line in upper case,
+// skipping position numbers and spaces
+func writeSequenceLine(b *strings.Builder, line string) {
+	for _, r := range line {
+		if unicode.IsLetter(r) {
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+}
+
+func joinDescription(name, definition string) string {
+	return strings.TrimSpace(name + " " + strings.TrimSuffix(definition, "."))
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type FlatFileParserTestSuite struct {
+	suite.Suite
+}
+
+func (s *FlatFileParserTestSuite) readAll(filename string) []*Sequence {
+	f, err := os.Open(filename)
+	s.Require().NoError(err)
+	defer f.Close()
+
+	reader, err := NewRecordReader(f, nil)
+	s.Require().NoError(err)
+
+	var seqs []*Sequence
+	for {
+		seq, err := reader.Next()
+		if err == io.EOF {
+			return seqs
+		}
+		s.Require().NoError(err)
+		seqs = append(seqs, seq)
+	}
+}
+
+func (s *FlatFileParserTestSuite) TestGenBank() {
+	seqs := s.readAll("testdata/multi.gb")
+	s.Require().Len(seqs, 2)
+
+	s.Equal("SCU49845 Saccharomyces cerevisiae TCP1-beta gene, partial cds, and Axl2p (AXL2) gene, complete cds",
+		seqs[0].Description)
+	s.Len(seqs[0].Value, 120)
+	s.Equal("GATCCTCCAT", seqs[0].Value[:10])
+	s.Equal("GTAGTCAGCT", seqs[0].Value[110:])
+
+	features := seqs[0].Features
+	s.Require().Len(features, 3)
+	s.Equal(Feature{
+		Key:      "source",
+		Location: "1..120",
+		Start:    1,
+		End:      120,
+		Qualifiers: []Qualifier{
+			{Name: "organism", Value: "Saccharomyces cerevisiae"},
+			{Name: "db_xref", Value: "taxon:4932"},
+			{Name: "chromosome", Value: "IX"},
+		},
+	}, features[0])
+
+	s.Equal("CDS", features[1].Key)
+	s.Equal(1, features[1].Start)
+	s.Equal(26, features[1].End)
+	translation, ok := features[1].Qualifier("translation")
+	s.True(ok)
+	s.Equal("SSIYNGISTSGLDLNNGTIADMRQLGIVESYKLKRAVVSSASEAAEVLLRVDNIIRARPRTANRQHM", translation)
+
+	s.Equal("complement(join(30..60,70..110))", features[2].Location)
+	s.True(features[2].Complement)
+	s.Equal(30, features[2].Start)
+	s.Equal(110, features[2].End)
+	s.Equal([]Qualifier{{Name: "gene", Value: "AXL2"}, {Name: "pseudo"}}, features[2].Qualifiers)
+
+	s.Equal("SHORT1 Short synthetic record", seqs[1].Description)
+	s.Equal("ACGTACGTACGTACGT", seqs[1].Value)
+	s.Require().Len(seqs[1].Features, 1)
+	note, _ := seqs[1].Features[0].Qualifier("note")
+	s.Equal("middle part", note)
+}
+
+func (s *FlatFileParserTestSuite) TestEMBL() {
+	seqs := s.readAll("testdata/multi.embl")
+	s.Require().Len(seqs, 2)
+
+	s.Equal("X56734 Trifolium repens mRNA for non-cyanogenic beta-glucosidase", seqs[0].Description)
+	s.Len(seqs[0].Value, 120)
+	s.Equal("AAACAAACCA", seqs[0].Value[:10])
+
+	features := seqs[0].Features
+	s.Require().Len(features, 2)
+	s.Equal("CDS", features[1].Key)
+	s.Equal(14, features[1].Start)
+	s.Equal(120, features[1].End)
+	note, _ := features[1].Qualifier("note")
+	s.Equal("partial coding sequence of the non-cyanogenic enzyme", note)
+
+	s.Equal("SHORT2 Short synthetic record", seqs[1].Description)
+	s.Equal("TTGGCCAATTGG", seqs[1].Value)
+	s.Require().Len(seqs[1].Features, 1)
+	s.True(seqs[1].Features[0].Complement)
+	rptType, _ := seqs[1].Features[0].Qualifier("rpt_type")
+	s.Equal("tandem", rptType)
+}
+
+func (s *FlatFileParserTestSuite) TestBadHeader() {
+	_, err := NewGenBankParser(strings.NewReader("DEFINITION nothing\n//\n")).Next()
+	s.Equal(ErrBadGenBankHeader, err)
+
+	_, err = NewEMBLParser(strings.NewReader("DE   nothing\n//\n")).Next()
+	s.Equal(ErrBadEMBLHeader, err)
+}
+
+func (s *FlatFileParserTestSuite) TestTruncatedRecord() {
+	for _, c := range []struct {
+		reader RecordReader
+		line   int
+	}{
+		{NewGenBankParser(strings.NewReader("LOCUS one\nORIGIN\n 1 acgt\n//\n\nLOCUS two\nORIGIN\n 1 acgt\n")), 6},
+		{NewEMBLParser(strings.NewReader("ID   one;\nSQ\n acgt\n//\nID   two;\nSQ\n acgt\n")), 5},
+	} {
+		seq, err := c.reader.Next()
+		s.Require().NoError(err)
+		s.Equal("ACGT", seq.Value)
+
+		_, err = c.reader.Next()
+		var parseErr *ParseError
+		s.Require().True(errors.As(err, &parseErr))
+		s.Equal(ErrTruncatedRecord, parseErr.Err)
+		s.Equal(2, parseErr.Record)
+		s.Equal(c.line, parseErr.Line)
+	}
+}
+
+func TestFlatFileParserSuite(t *testing.T) {
+	suite.Run(t, new(FlatFileParserTestSuite))
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type FastaParserTestSuite struct {
+	suite.Suite
+}
+
+func (s *FastaParserTestSuite) readAll(parser *FastaParser) ([]*Sequence, error) {
+	var seqs []*Sequence
+	for {
+		seq, err := parser.Next()
+		if err == io.EOF {
+			return seqs, nil
+		}
+		if err != nil {
+			return seqs, err
+		}
+		seqs = append(seqs, seq)
+	}
+}
+
+func (s *FastaParserTestSuite) TestDefault() {
+	seqs, err := s.readAll(NewFastaParser(strings.NewReader(
+		"; comment before records\n" +
+			">seq1 first\n" +
+			"AC GT\r\n" +
+			"; comment inside record\n" +
+			"A>C\n" +
+			"\n" +
+			">empty\n" +
+			">seq3\n" +
+			"TT",
+	)))
+	s.Require().NoError(err)
+	s.Equal([]*Sequence{
+		{Description: "seq1 first", Value: "ACGTA>C"},
+		{Description: "empty", Value: ""},
+		{Description: "seq3", Value: "TT"},
+	}, seqs)
+}
+
+func (s *FastaParserTestSuite) TestErrorLocation() {
+	_, err := NewFastaParser(strings.NewReader("\n\nACGT\n")).Next()
+	s.Equal(&ParseError{Record: 1, Line: 3, Column: 1, Err: ErrBadHeader}, err)
+	s.Equal("fasta parser: bad header (record 1, line 3, column 1)", err.Error())
+	s.Equal(ErrBadHeader, errors.Cause(err))
+
+	_, err = s.readAll(NewFastaParserMode(strings.NewReader(">seq1\nAC\n; comment\n>seq2\n"), FastaStrict, nil))
+	s.Equal(&ParseError{Record: 2, Line: 4, Column: 1, Err: ErrEmptyRecord}, err)
+}
+
+func (s *FastaParserTestSuite) TestStrict() {
+	for _, c := range []struct {
+		data   string
+		line   int
+		column int
+		err    error
+	}{
+		{data: ">seq1\n>seq2\nAC\n", line: 1, column: 1, err: ErrEmptyRecord},
+		{data: ">seq1\nAC GT\n", line: 2, column: 3, err: ErrUnexpectedSpace},
+		{data: ">seq1\nAC\tGT\n", line: 2, column: 3, err: ErrUnexpectedSpace},
+		{data: ">seq1\nAC\r\nGT\n", line: 2, column: 3, err: ErrMixedLineEndings},
+		{data: ">seq1\nAC\x01GT\n", line: 2, column: 3, err: ErrNonPrintable},
+		{data: ">seq\x00\nACGT\n", line: 1, column: 5, err: ErrNonPrintable},
+		// колонки считаются в символах, а не в байтах
+		{data: ">seq1\nхор ошо\n", line: 2, column: 4, err: ErrUnexpectedSpace},
+		{data: ">сек\x00\nACGT\n", line: 1, column: 5, err: ErrNonPrintable},
+		{data: ">seq1\nРЫБА\r\nGT\n", line: 2, column: 5, err: ErrMixedLineEndings},
+	} {
+		_, err := s.readAll(NewFastaParserMode(strings.NewReader(c.data), FastaStrict, nil))
+		var parseErr *ParseError
+		s.Require().True(errors.As(err, &parseErr), c.data)
+		s.Equal(c.line, parseErr.Line, c.data)
+		s.Equal(c.column, parseErr.Column, c.data)
+		s.Equal(c.err, errors.Cause(parseErr.Err), c.data)
+	}
+
+	seqs, err := s.readAll(NewFastaParserMode(strings.NewReader(">seq1\tfirst\r\nACGT\r\nAC\r\n"), FastaStrict, nil))
+	s.Require().NoError(err)
+	s.Equal([]*Sequence{{Description: "seq1\tfirst", Value: "ACGTAC"}}, seqs)
+
+	seqs, err = s.readAll(NewFastaParserMode(strings.NewReader(">a\nхорошо\n>b\nРЫБА\n"), FastaStrict, nil))
+	s.Require().NoError(err)
+	s.Equal([]*Sequence{{Description: "a", Value: "хорошо"}, {Description: "b", Value: "РЫБА"}}, seqs)
+}
+
+func (s *FastaParserTestSuite) TestLenient() {
+	var warnings []error
+	parser := NewFastaParserMode(strings.NewReader(
+		"garbage\n"+
+			">seq1\n"+
+			"ACGT\n"+
+			">empty\n"+
+			">bad\n"+
+			"AC\x01\n"+
+			"GT\n"+
+			">seq2\n"+
+			"TTTT\n",
+	), FastaLenient, func(err error) {
+		warnings = append(warnings, err)
+	})
+
+	seqs, err := s.readAll(parser)
+	s.Require().NoError(err)
+	s.Equal([]*Sequence{
+		{Description: "seq1", Value: "ACGT"},
+		{Description: "seq2", Value: "TTTT"},
+	}, seqs)
+	s.Require().Len(warnings, 3)
+	s.Equal(ErrBadHeader, errors.Cause(warnings[0]))
+	s.Equal(ErrEmptyRecord, errors.Cause(warnings[1]))
+	s.Equal(ErrNonPrintable, errors.Cause(errors.Cause(warnings[2])))
+}
+
+func (s *FastaParserTestSuite) TestMultiByteFile() {
+	// х кодируется как D1 85, второй байт не должен считаться пробелом ни в одном режиме
+	for _, mode := range []FastaMode{FastaDefault, FastaStrict, FastaLenient} {
+		seqs, err := loadSequences([]string{"testdata/cyrillic.fa"}, &SelectConfig{}, &ReaderConfig{FastaMode: mode})
+		s.Require().NoError(err, mode)
+		s.Equal("хорошо", seqs[0].Value, mode)
+		s.Equal("хорош", seqs[1].Value, mode)
+
+		cfg := &SequenceAlignerConfig{GapPenalty: -2, GapStartPenalty: true, GapEndPenalty: true}
+		a, b, score := NewSequenceAligner(cfg, NewDefaultAdapter(1, -1)).Align(seqs[0].Value, seqs[1].Value)
+		s.Equal("хорошо", a, mode)
+		s.Equal("хорош-", b, mode)
+		s.Equal(3, score, mode)
+	}
+}
+
+func TestFastaParserSuite(t *testing.T) {
+	suite.Run(t, new(FastaParserTestSuite))
+}
@@ -2,9 +2,8 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"io"
-
-	"github.com/fatih/color"
 )
 
 var (
@@ -12,25 +11,51 @@ var (
 	ErrNotAligned = errors.New("aligned write: sequences are not aligned")
 )
 
+const (
+	// prettyPrefixWidth и prettySuffixWidth ширина имени и номеров символов вокруг части выравнивания в WritePretty
+	prettyPrefixWidth = len("seq1: ") + 7
+	prettySuffixWidth = 7
+	// minPrettyLineLength наименьшая длина строки при подборе по ширине терминала
+	minPrettyLineLength = 10
+)
+
 // WriteAlignedDefault запись выровненных последовательностей
 // в стандартном формате с переносом каждые lineLength символов
 func WriteAlignedDefault(w io.Writer, lineLength int, a, b string) error {
-	if len(a) == 0 {
+	return writeAligned(w, lineLength, nil, false, a, b)
+}
+
+// WriteAlignedMatch запись выровненных последовательностей в стандартном формате
+// со строкой разметки между ними. Похожие символы находятся с помощью scorer, nil scorer не находит похожих.
+func WriteAlignedMatch(w io.Writer, lineLength int, scorer Scorer, a, b string) error {
+	return writeAligned(w, lineLength, scorer, true, a, b)
+}
+
+func writeAligned(w io.Writer, lineLength int, scorer Scorer, matchLine bool, a, b string) error {
+	runesA, runesB := []rune(a), []rune(b)
+	if len(runesA) == 0 {
 		return nil
 	}
-	if len(a) != len(b) {
+	if len(runesA) != len(runesB) {
 		return ErrNotAligned
 	}
 
-	seqLen := len(a)
+	seqLen := len(runesA)
 	l, r := 0, MinInt(seqLen, lineLength)
 
 	for l < seqLen {
 		io.WriteString(w, "seq1: ")
-		io.WriteString(w, a[l:r])
+		io.WriteString(w, string(runesA[l:r]))
 		io.WriteString(w, "\n")
+		if matchLine {
+			io.WriteString(w, "      ") // len("seq1: ")
+			for i := l; i < r; i++ {
+				io.WriteString(w, string(markupSymbol(scorer, runesA[i], runesB[i])))
+			}
+			io.WriteString(w, "\n")
+		}
 		io.WriteString(w, "seq2: ")
-		io.WriteString(w, b[l:r])
+		io.WriteString(w, string(runesB[l:r]))
 		io.WriteString(w, "\n")
 
 		l, r = r, MinInt(seqLen, r+lineLength)
@@ -39,54 +64,70 @@ func WriteAlignedDefault(w io.Writer, lineLength int, a, b string) error {
 	return nil
 }
 
-// WritePretty выводит в разноцветном формате
-// в одну строчку с дополнительными символами
-func WritePretty(w io.Writer, a, b string) error {
-	if len(a) == 0 {
+// WritePretty выводит выравнивание res в разноцветном формате с переносом каждые lineLength символов.
+// Каждая строка последовательности начинается и заканчивается номерами первого и последнего её символа (с 1),
+// между строками последовательностей выводится строка разметки в стиле EMBOSS.
+// Цвета выбирает theme, nil theme означает MatchColorTheme.
+func WritePretty(w io.Writer, lineLength int, scorer Scorer, theme ColorTheme, res *AlignResult) error {
+	runesA, runesB := []rune(res.Aligned1), []rune(res.Aligned2)
+	if len(runesA) == 0 {
 		return nil
 	}
-	if len(a) != len(b) {
+	if len(runesA) != len(runesB) {
 		return ErrNotAligned
 	}
-	seqLen := len(a)
-
-	redAdapter := color.New(color.FgRed)     // gap
-	greenAdapter := color.New(color.FgGreen) // match
-	blueAdapter := color.New(color.FgBlue)   // missmatch
-	getAdapter := func(b1, b2 byte) *color.Color {
-		if b1 == gapByte || b2 == gapByte {
-			return redAdapter
-		} else if b1 == b2 {
-			return greenAdapter
-		} else {
-			return blueAdapter
-		}
+	seqLen := len(runesA)
+
+	if theme == nil {
+		theme = NewMatchColorTheme()
 	}
-	symbolAdapter := func(b1, b2 byte) string {
-		if b1 == gapByte || b2 == gapByte {
-			return " "
-		} else if b1 == b2 {
-			return "*"
-		} else {
-			return "|"
+	writeSymbol := func(r, markup rune) {
+		if c := theme.Color(r, markup); c != nil {
+			c.Fprint(w, string(r))
+			return
 		}
+		io.WriteString(w, string(r))
 	}
 
-	io.WriteString(w, "seq1: ")
-	for i := 0; i < seqLen; i++ {
-		getAdapter(a[i], b[i]).Fprint(w, string(a[i]))
+	markup := make([]rune, seqLen)
+	for i := range markup {
+		markup[i] = markupSymbol(scorer, runesA[i], runesB[i])
 	}
-	io.WriteString(w, "\n")
-	io.WriteString(w, "      ") // len("seq1: ")
-	for i := 0; i < seqLen; i++ {
-		getAdapter(a[i], b[i]).Fprint(w, symbolAdapter(a[i], b[i]))
+	writeLine := func(name string, pos int, runes []rune, l, r int) int {
+		start := pos
+		for _, b := range runes[l:r] {
+			if b != gapRune {
+				pos++
+			}
+		}
+		// в строке из одних gap повторяется номер последнего символа
+		if pos > start {
+			start++
+		}
+		fmt.Fprintf(w, "%s %6d ", name, start)
+		for i := l; i < r; i++ {
+			writeSymbol(runes[i], markup[i])
+		}
+		fmt.Fprintf(w, " %6d\n", pos)
+		return pos
 	}
-	io.WriteString(w, "\n")
-	io.WriteString(w, "seq2: ")
-	for i := 0; i < seqLen; i++ {
-		getAdapter(a[i], b[i]).Fprint(w, string(b[i]))
+
+	pos1, pos2 := res.Start1, res.Start2
+	l, r := 0, MinInt(seqLen, lineLength)
+	for l < seqLen {
+		pos1 = writeLine("seq1:", pos1, runesA, l, r)
+		fmt.Fprintf(w, "%*s", prettyPrefixWidth, "")
+		for i := l; i < r; i++ {
+			writeSymbol(markup[i], markup[i])
+		}
+		io.WriteString(w, "\n")
+		pos2 = writeLine("seq2:", pos2, runesB, l, r)
+
+		l, r = r, MinInt(seqLen, r+lineLength)
+		if l < seqLen {
+			io.WriteString(w, "\n")
+		}
 	}
-	io.WriteString(w, "\n")
 
 	return nil
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/suite"
+)
+
+type PrintTestSuite struct {
+	suite.Suite
+}
+
+func (s *PrintTestSuite) TestWriteAlignedMatch() {
+	var buf bytes.Buffer
+	s.Require().NoError(WriteAlignedMatch(&buf, 4, NewProteinAdapterBLOSUM62(), "AWGHEI", "AW-HEV"))
+	s.Equal(""+
+		"seq1: AWGH\n"+
+		"      || |\n"+
+		"seq2: AW-H\n"+
+		"seq1: EI\n"+
+		"      |:\n"+
+		"seq2: EV\n", buf.String())
+
+	s.Equal(ErrNotAligned, WriteAlignedMatch(&buf, 4, nil, "AW", "A"))
+}
+
+func (s *PrintTestSuite) TestWritePretty() {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	var buf bytes.Buffer
+	res := &AlignResult{Aligned1: "AWGHEI", Aligned2: "AW-HEV", Start1: 4, End1: 10, Start2: 1, End2: 6, Len1: 10, Len2: 6}
+	s.Require().NoError(WritePretty(&buf, 3, NewProteinAdapterBLOSUM62(), nil, res))
+	s.Equal(""+
+		"seq1:      5 AWG      7\n"+
+		"             || \n"+
+		"seq2:      2 AW-      3\n"+
+		"\n"+
+		"seq1:      8 HEI     10\n"+
+		"             ||:\n"+
+		"seq2:      4 HEV      6\n", buf.String())
+}
+
+func TestPrintSuite(t *testing.T) {
+	suite.Run(t, new(PrintTestSuite))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// ErrWeightsLength возвращается, если число весов не совпадает с числом выравниваемых символов
+var ErrWeightsLength = errors.New("number of weights does not match sequence length")
+
+// phredOffset смещение кодировки качества Phred+33
+const phredOffset = 33
+
+// weightScale множитель оценок при выравнивании с весами: взвешенные оценки хранятся
+// как целые с фиксированной точкой, поэтому веса учитываются с точностью до 1/weightScale
+const weightScale = 1000
+
+// MatchWeights возвращает для каждого символа качества вероятность его правильного прочтения 1 - 10^(-Q/10),
+// где Q — качество по шкале Phred. Если качество не задано, возвращает nil.
+// Строка качества FASTQ содержит по символу на каждый символ последовательности.
+func (s *Sequence) MatchWeights() []float64 {
+	if s.Quality == "" {
+		return nil
+	}
+
+	weights := make([]float64, len(s.Quality))
+	for i := 0; i < len(s.Quality); i++ {
+		q := MaxInt(int(s.Quality[i])-phredOffset, 0)
+		weights[i] = 1 - math.Pow(10, -float64(q)/10)
+	}
+	return weights
+}
+
+// checkWeights проверяет, что веса w заданы для каждого из n выравниваемых символов последовательности name
+func checkWeights(w []float64, n int, name string) error {
+	if w != nil && len(w) != n {
+		return errors.Wrapf(ErrWeightsLength, "%s sequence has %d symbols, got %d weights", name, n, len(w))
+	}
+	return nil
+}
+
+// weightedScore возвращает оценку, умножающую оценку совмещения i-го и j-го символов на w1[i]*w2[j],
+// в масштабе weightScale. nil вместо весов означает, что все веса последовательности равны 1.
+func weightedScore(score scoreFunc, w1, w2 []float64) scoreFunc {
+	return func(i, j int) int {
+		w := 1.0
+		if w1 != nil {
+			w *= w1[i]
+		}
+		if w2 != nil {
+			w *= w2[j]
+		}
+		return int(math.Round(float64(score(i, j)) * w * weightScale))
+	}
+}
+
+// unscaleScore переводит оценку в масштабе weightScale в обычную, округляя её до целого
+func unscaleScore(score int) int {
+	return int(math.Round(float64(score) / weightScale))
+}
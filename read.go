@@ -1,50 +1,153 @@
 package main
 
-import "os"
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// stdinName имя файла, означающее стандартный ввод
+const stdinName = "-"
+
+var (
+	// ErrUnsupportedCompression возвращается для сжатых форматов, которые не умеем распаковывать
+	ErrUnsupportedCompression = errors.New("zstd compressed input is not supported, decompress it first")
+	// ErrStdinTwice возвращается, если стандартный ввод указан в качестве обоих файлов
+	ErrStdinTwice = errors.New("standard input can be used only once")
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decompressReader читает распакованные данные и закрывает исходный файл
+type decompressReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *decompressReader) Close() error {
+	var err error
+	for _, c := range r.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// isCompressed проверяет по магическим байтам, что файл сжат, и возвращает позицию чтения в начало
+func isCompressed(f *os.File) (bool, error) {
+	magic := make([]byte, len(zstdMagic))
+	n, err := f.Read(magic)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	magic = magic[:n]
+	return bytes.HasPrefix(magic, gzipMagic) || bytes.HasPrefix(magic, bzip2Magic) || bytes.HasPrefix(magic, zstdMagic), nil
+}
+
+// openSequenceFile открывает файл с последовательностями, stdinName означает стандартный ввод.
+// Файлы, сжатые gzip или bzip2, распаковываются прозрачно, формат определяется по магическим байтам.
+func openSequenceFile(filename string) (io.ReadCloser, error) {
+	if filename == stdinName {
+		// закрытие результата не должно закрывать стандартный ввод
+		return newDecompressReader(io.NopCloser(os.Stdin))
+	}
 
-func readNFromFile(filename string, n int) ([]*Sequence, error) {
 	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	parser := NewFastaParser(f)
-	seqs := make([]*Sequence, n)
-	for i := 0; i < n; i++ {
-		s, err := parser.Next()
+	return newDecompressReader(f)
+}
+
+// newDecompressReader возвращает распакованное содержимое f, если оно сжато gzip или bzip2, иначе само содержимое.
+// Закрытие результата закрывает f, при ошибке f закрывается сразу.
+func newDecompressReader(f io.ReadCloser) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(f)
+	// ошибка означает, что данных меньше, чем длина магических байт, тогда это точно не архив
+	magic, _ := buffered.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(buffered)
 		if err != nil {
+			f.Close()
 			return nil, err
 		}
-		seqs[i] = s
+		return &decompressReader{Reader: gz, closers: []io.Closer{gz, f}}, nil
+	case bytes.HasPrefix(magic, bzip2Magic):
+		return &decompressReader{Reader: bzip2.NewReader(buffered), closers: []io.Closer{f}}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		f.Close()
+		return nil, ErrUnsupportedCompression
 	}
-
-	return seqs, nil
+	return &decompressReader{Reader: buffered, closers: []io.Closer{f}}, nil
 }
 
-func loadFromFile(filename string) ([]*Sequence, error) {
-	return readNFromFile(filename, 2)
+// recordSource лениво читает записи файла, каждая запись читается один раз
+type recordSource struct {
+	filename string
+	file     io.ReadCloser
+	reader   RecordReader
+	records  []*Sequence
+	done     bool
 }
 
-func loadFromFiles(filename1, filename2 string) ([]*Sequence, error) {
-	s1, err := readNFromFile(filename1, 1)
+func openRecordSource(filename string, cfg *ReaderConfig) (*recordSource, error) {
+	f, err := openSequenceFile(filename)
 	if err != nil {
-		return nil, err
+		// ошибки открытия файла уже содержат его имя
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			return nil, err
+		}
+		return nil, errors.Wrap(err, filename)
 	}
-
-	s2, err := readNFromFile(filename2, 1)
+	reader, err := NewRecordReader(f, cfg)
 	if err != nil {
-		return nil, err
+		f.Close()
+		return nil, errors.Wrap(err, filename)
 	}
-
-	return append(s1, s2...), nil
+	return &recordSource{filename: filename, file: f, reader: reader}, nil
 }
 
-func loadSequences(fileNames []string) ([]*Sequence, error) {
-	if len(fileNames) == 1 {
-		return loadFromFile(fileNames[0])
+// readUntil читает записи, пока их не станет больше n или файл не закончится
+func (s *recordSource) readUntil(n int) error {
+	for !s.done && len(s.records) <= n {
+		seq, err := s.reader.Next()
+		if err == io.EOF {
+			s.done = true
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, s.filename)
+		}
+		s.records = append(s.records, seq)
 	}
-	if len(fileNames) == 2 {
-		return loadFromFiles(fileNames[0], fileNames[1])
+	return nil
+}
+
+// all возвращает все записи файла
+func (s *recordSource) all() ([]*Sequence, error) {
+	for !s.done {
+		if err := s.readUntil(len(s.records)); err != nil {
+			return nil, err
+		}
 	}
-	return nil, ErrWrongNumberOfFiles
+	return s.records, nil
+}
+
+func (s *recordSource) Close() error {
+	return s.file.Close()
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReadTestSuite struct {
+	suite.Suite
+}
+
+// bzip2Fasta ">a\nACGT\n", сжатый bzip2: в стандартной библиотеке нет упаковщика bzip2
+var bzip2Fasta = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x27, 0x0b, 0x89, 0x03, 0x00, 0x00,
+	0x01, 0x4f, 0x00, 0x00, 0x10, 0x00, 0x01, 0x28, 0x80, 0x04, 0x00, 0x20, 0x00, 0x20, 0x00, 0x31,
+	0x0c, 0x01, 0x06, 0x99, 0xa4, 0x16, 0x38, 0x14, 0x5d, 0xc9, 0x14, 0xe1, 0x42, 0x40, 0x9c, 0x2e,
+	0x24, 0x0c,
+}
+
+// trackingCloser запоминает, был ли закрыт источник
+type trackingCloser struct {
+	io.Reader
+	closed bool
+}
+
+func (c *trackingCloser) Close() error {
+	c.closed = true
+	return nil
+}
+
+func gzipData(data string) []byte {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	w.Write([]byte(data))
+	w.Close()
+	return buf.Bytes()
+}
+
+func (s *ReadTestSuite) TestDecompressReader() {
+	for _, c := range []struct {
+		name string
+		data []byte
+		exp  string
+		err  error
+	}{
+		{name: "plain", data: []byte(">a\nACGT\n"), exp: ">a\nACGT\n"},
+		{name: "short", data: []byte(">a"), exp: ">a"},
+		{name: "empty", data: nil, exp: ""},
+		{name: "gzip", data: gzipData(">a\nACGT\n"), exp: ">a\nACGT\n"},
+		{name: "bzip2", data: bzip2Fasta, exp: ">a\nACGT\n"},
+		{name: "zstd", data: append(append([]byte{}, zstdMagic...), 0, 0, 0), err: ErrUnsupportedCompression},
+	} {
+		src := &trackingCloser{Reader: bytes.NewBuffer(c.data)}
+		r, err := newDecompressReader(src)
+		if c.err != nil {
+			s.True(errors.Is(err, c.err), c.name)
+			s.True(src.closed, c.name)
+			continue
+		}
+		s.Require().NoError(err, c.name)
+
+		data, err := io.ReadAll(r)
+		s.Require().NoError(err, c.name)
+		s.Equal(c.exp, string(data), c.name)
+		s.False(src.closed, c.name)
+		s.NoError(r.Close(), c.name)
+		s.True(src.closed, c.name)
+	}
+}
+
+func (s *ReadTestSuite) TestBrokenGzip() {
+	src := &trackingCloser{Reader: bytes.NewBuffer(append(append([]byte{}, gzipMagic...), 0))}
+	_, err := newDecompressReader(src)
+	s.Error(err)
+	s.True(src.closed)
+}
+
+func (s *ReadTestSuite) TestStdin() {
+	f, err := os.CreateTemp(s.T().TempDir(), "stdin")
+	s.Require().NoError(err)
+	_, err = f.Write(gzipData(">a\nACGT\n"))
+	s.Require().NoError(err)
+	_, err = f.Seek(0, io.SeekStart)
+	s.Require().NoError(err)
+	defer f.Close()
+
+	stdin := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = stdin }()
+
+	r, err := openSequenceFile(stdinName)
+	s.Require().NoError(err)
+	data, err := io.ReadAll(r)
+	s.Require().NoError(err)
+	s.Equal(">a\nACGT\n", string(data))
+
+	// стандартный ввод остаётся открытым
+	s.Require().NoError(r.Close())
+	_, err = f.Stat()
+	s.NoError(err)
+}
+
+func TestReadSuite(t *testing.T) {
+	suite.Run(t, new(ReadTestSuite))
+}
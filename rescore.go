@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Ошибки чтения пары выровненных последовательностей
+var (
+	ErrUnknownAlignmentFormat = errors.New("unknown alignment format, expected aligned fasta or clustal")
+	ErrAlignedPairCount       = errors.New("expected exactly two aligned sequences")
+)
+
+// clustalHeaders начала первой строки файлов в формате Clustal
+var clustalHeaders = []string{"CLUSTAL", "MUSCLE"}
+
+// ReadAlignedPair читает пару выровненных последовательностей в формате aligned FASTA или Clustal.
+// Формат определяется по первой непустой строке. Колонки из одних gap отбрасываются,
+// а выравнивание покрывает обе последовательности целиком. Оценка выравнивания не вычисляется.
+func ReadAlignedPair(r io.Reader) (*Sequence, *Sequence, *AlignResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	first := bytes.TrimLeft(data, " \t\r\n")
+	if len(first) == 0 {
+		return nil, nil, nil, errors.Wrap(ErrAlignedPairCount, "got 0")
+	}
+
+	var names, aligned []string
+	switch {
+	case first[0] == '>':
+		names, aligned, err = readAlignedFasta(bytes.NewReader(first))
+	case isClustalHeader(string(first)):
+		names, aligned, err = readClustal(bytes.NewReader(first))
+	default:
+		return nil, nil, nil, ErrUnknownAlignmentFormat
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(aligned) != 2 {
+		return nil, nil, nil, errors.Wrapf(ErrAlignedPairCount, "got %d", len(aligned))
+	}
+
+	res, err := newAlignedPairResult(aligned[0], aligned[1])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	seq1 := &Sequence{Description: names[0], Value: strings.ReplaceAll(res.Aligned1, string(gapRune), "")}
+	seq2 := &Sequence{Description: names[1], Value: strings.ReplaceAll(res.Aligned2, string(gapRune), "")}
+	return seq1, seq2, res, nil
+}
+
+func isClustalHeader(line string) bool {
+	for _, header := range clustalHeaders {
+		if strings.HasPrefix(line, header) {
+			return true
+		}
+	}
+	return false
+}
+
+// readAlignedFasta читает описания и выровненные строки записей FASTA
+func readAlignedFasta(r io.Reader) ([]string, []string, error) {
+	var names, aligned []string
+	err := ScanFasta(r, func(header, value []byte) error {
+		names = append(names, string(header))
+		aligned = append(aligned, string(value))
+		return nil
+	})
+	return names, aligned, err
+}
+
+// readClustal читает имена и выровненные строки файла Clustal.
+// Строки блоков имеют вид "имя выровненная_часть [номер]", строки разметки начинаются с пробела.
+func readClustal(r io.Reader) ([]string, []string, error) {
+	var names []string
+	parts := make(map[string]*strings.Builder)
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if lineNum == 1 || strings.TrimSpace(line) == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, nil, errors.Errorf("clustal line %d: expected name and aligned sequence", lineNum)
+		}
+		part, ok := parts[fields[0]]
+		if !ok {
+			part = &strings.Builder{}
+			parts[fields[0]] = part
+			names = append(names, fields[0])
+		}
+		part.WriteString(fields[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	aligned := make([]string, len(names))
+	for i, name := range names {
+		aligned[i] = parts[name].String()
+	}
+	return names, aligned, nil
+}
+
+// newAlignedPairResult возвращает выравнивание строк a и b целиком без колонок из одних gap
+func newAlignedPairResult(a, b string) (*AlignResult, error) {
+	runesA, runesB := []rune(a), []rune(b)
+	if len(runesA) != len(runesB) {
+		return nil, errors.Wrapf(ErrNotAligned, "lengths %d and %d", len(runesA), len(runesB))
+	}
+
+	res := &AlignResult{}
+	aligned1, aligned2 := &strings.Builder{}, &strings.Builder{}
+	for i := range runesA {
+		if runesA[i] == gapRune && runesB[i] == gapRune {
+			continue
+		}
+		if runesA[i] != gapRune {
+			res.Len1++
+		}
+		if runesB[i] != gapRune {
+			res.Len2++
+		}
+		aligned1.WriteRune(runesA[i])
+		aligned2.WriteRune(runesB[i])
+	}
+	res.End1, res.End2 = res.Len1, res.Len2
+	res.Aligned1, res.Aligned2 = aligned1.String(), aligned2.String()
+	return res, nil
+}
+
+// Rescore возвращает оценку выравнивания res по тем же правилам, что и выравниватели:
+// совмещения оцениваются scorer, а gap штрафуются в соответствии со scoring, включая правила для концевых gap.
+// Колонки вне res (например, soft clip) не учитываются, поэтому для сравнения с глобальным выравниванием
+// res должно покрывать последовательности целиком, см. withEndGaps.
+func Rescore(res *AlignResult, scorer Scorer, scoring *ScoringInfo) int {
+	score := 0
+	for _, s := range columnScores(res, scorer, scoring) {
+		score += s
+	}
+	return score
+}
+
+// runRescore оценивает готовое выравнивание с параметрами оценки из флагов
+// и сравнивает оценку с оценкой выравнивания, найденного Aligner
+func runRescore(args []string) error {
+	fs := flag.NewFlagSet("rescore", flag.ContinueOnError)
+	cigarStr := fs.String("cigar", "", "alignment as cigar of sequences selected like for alignment")
+	pos := fs.Int("pos", 1, "position (from 1) in the first sequence where the cigar alignment starts")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var seq1, seq2 *Sequence
+	var res *AlignResult
+	if *cigarStr == "" {
+		if fs.NArg() != 1 {
+			return errors.New("expected one aligned fasta or clustal file")
+		}
+		f, err := openSequenceFile(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if seq1, seq2, res, err = ReadAlignedPair(f); err != nil {
+			return errors.Wrap(err, fs.Arg(0))
+		}
+	} else {
+		c, err := ParseCigar(*cigarStr)
+		if err != nil {
+			return err
+		}
+		selectCfg, err := buildSelectConfig()
+		if err != nil {
+			return errors.Wrap(err, "can not parse --select")
+		}
+		sequences, err := loadSequences(fs.Args(), selectCfg, buildReaderConfig())
+		if err != nil {
+			return err
+		}
+		seq1, seq2 = sequences[0], sequences[1]
+		if res, err = c.Apply(seq1.Value, seq2.Value, *pos-1); err != nil {
+			return err
+		}
+	}
+
+	adapter, aligner, scoring, err := buildScoring()
+	if err != nil {
+		return errors.Wrap(err, "can not set up scoring")
+	}
+	if err := validate(adapter, []*Sequence{seq1, seq2}); err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if outputFile != "" {
+		if out, err = os.Create(outputFile); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	return rescorePair(out, seq1, seq2, res, adapter, aligner, scoring)
+}
+
+// rescorePair оценивает выравнивание res последовательностей seq1 и seq2, выравнивает их aligner
+// и выводит обе оценки. В глобальном режиме символы вне res (soft clip, начало до -pos и т.п.)
+// оцениваются как концевые gap, в локальном режиме, как и в локальном выравнивании, не учитываются.
+func rescorePair(w io.Writer, seq1, seq2 *Sequence, res *AlignResult, scorer Scorer, aligner Aligner, scoring *ScoringInfo) error {
+	if !scoring.Local {
+		res = res.withEndGaps(seq1.Value, seq2.Value)
+	}
+	score := Rescore(res, scorer, scoring)
+	best, err := aligner.AlignDetailed(seq1.Value, seq2.Value, nil, nil)
+	if err != nil {
+		return err
+	}
+	return writeRescore(w, score, best.Score)
+}
+
+// writeRescore выводит оценку выравнивания score и оценку best выравнивания, найденного Aligner.
+// Оценка выше найденной выравнивателем означает, что параметры оценки не совпадают с параметрами выравнивателя.
+func writeRescore(w io.Writer, score, best int) error {
+	verdict := "alignment is optimal"
+	switch {
+	case best > score:
+		verdict = "better alignment exists"
+	case score > best:
+		verdict = "alignment scores above the aligner, scoring options do not match it"
+	}
+	_, err := fmt.Fprintf(w, "Score: %d\nAligner score: %d (%s)\n", score, best, verdict)
+	return err
+}
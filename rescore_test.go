@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type RescoreTestSuite struct {
+	suite.Suite
+}
+
+func (s *RescoreTestSuite) TestReadAlignedPair() {
+	for _, c := range []struct {
+		input   string
+		comment string
+	}{
+		{
+			input:   ">s1 first\nAC-GT-\nAC\n>s2\nA-CGT-\n-C\n",
+			comment: "aligned fasta",
+		},
+		{
+			input: "CLUSTAL W (1.83) multiple sequence alignment\n\n" +
+				"s1      AC-GT- 4\n" +
+				"s2      A-CGT- 4\n" +
+				"        * ***\n\n" +
+				"s1      AC\n" +
+				"s2      -C\n",
+			comment: "clustal",
+		},
+	} {
+		seq1, seq2, res, err := ReadAlignedPair(strings.NewReader(c.input))
+		s.Require().NoError(err, c.comment)
+		s.Equal("ACGTAC", seq1.Value, c.comment)
+		s.Equal("ACGTC", seq2.Value, c.comment)
+		// колонка из одних gap отброшена
+		s.Equal("AC-GTAC", res.Aligned1, c.comment)
+		s.Equal("A-CGT-C", res.Aligned2, c.comment)
+		s.Equal([]int{0, 6, 6, 0, 5, 5}, []int{res.Start1, res.End1, res.Len1, res.Start2, res.End2, res.Len2}, c.comment)
+	}
+
+	_, _, _, err := ReadAlignedPair(strings.NewReader(">a\nAC\n>b\nACG\n"))
+	s.Equal(ErrNotAligned, errors.Cause(err))
+	_, _, _, err = ReadAlignedPair(strings.NewReader(">a\nAC\n"))
+	s.Equal(ErrAlignedPairCount, errors.Cause(err))
+	_, _, _, err = ReadAlignedPair(strings.NewReader("a AC\n"))
+	s.Equal(ErrUnknownAlignmentFormat, errors.Cause(err))
+}
+
+// TestRescoreMatchesAligners проверяет, что оценка выравнивания, найденного любым выравнивателем,
+// совпадает с его собственной оценкой при всех правилах концевых gap
+func (s *RescoreTestSuite) TestRescoreMatchesAligners() {
+	rnd := rand.New(rand.NewSource(1))
+	randomDNA := func() string {
+		b := make([]byte, 1+rnd.Intn(20))
+		for i := range b {
+			b[i] = "ACGT"[rnd.Intn(4)]
+		}
+		return string(b)
+	}
+	scorer := NewDNAAdapter()
+
+	for _, startPenalty := range []bool{false, true} {
+		for _, endPenalty := range []bool{false, true} {
+			cfg := &SequenceAlignerConfig{GapPenalty: -7, GapStartPenalty: startPenalty, GapEndPenalty: endPenalty}
+			linear := &ScoringInfo{GapOpen: -7, GapExtend: -7, GapStartPenalty: startPenalty, GapEndPenalty: endPenalty}
+			extend := *linear
+			extend.GapExtend = -2
+			local := *cfg
+			local.AllowLocal = true
+			localScoring := *linear
+			localScoring.Local = true
+
+			for _, c := range []struct {
+				aligner Aligner
+				scoring *ScoringInfo
+				name    string
+			}{
+				{NewSequenceAligner(cfg, scorer), linear, "linear"},
+				{NewSequenceAligner(&local, scorer), &localScoring, "local"},
+				{NewSequenceAlignerExtend(&SequenceAlignerExtendConfig{*cfg, -2}, scorer), &extend, "extend"},
+			} {
+				for k := 0; k < 50; k++ {
+					seq1, seq2 := randomDNA(), randomDNA()
+					res := alignDetailed(c.aligner, seq1, seq2)
+					s.Equal(res.Score, Rescore(res, scorer, c.scoring),
+						"%s spen=%v epen=%v %s/%s: %s %s", c.name, startPenalty, endPenalty, seq1, seq2, res.Aligned1, res.Aligned2)
+				}
+			}
+
+			// SequenceAlignerMem при бесплатных концевых gap возвращает оценку, не соответствующую выравниванию
+			if startPenalty && endPenalty {
+				mem := NewSequenceAlignerMem(cfg, scorer)
+				for k := 0; k < 50; k++ {
+					seq1, seq2 := randomDNA(), randomDNA()
+					res := alignDetailed(mem, seq1, seq2)
+					s.Equal(res.Score, Rescore(res, scorer, linear), "mem %s/%s", seq1, seq2)
+				}
+			}
+		}
+	}
+}
+
+func (s *RescoreTestSuite) TestRescoreCigar() {
+	seq1 := &Sequence{Value: "TTACGTACGT"}
+	seq2 := &Sequence{Value: "ACGTACGTGG"}
+	scorer := NewDefaultAdapter(1, -1)
+	cfg := &SequenceAlignerConfig{GapPenalty: -2, GapStartPenalty: true, GapEndPenalty: true}
+	scoring := &ScoringInfo{GapOpen: -2, GapExtend: -2, GapStartPenalty: true, GapEndPenalty: true}
+
+	rescore := func(cigar string, pos int, aligner Aligner, scoring *ScoringInfo) string {
+		c, err := ParseCigar(cigar)
+		s.Require().NoError(err)
+		res, err := c.Apply(seq1.Value, seq2.Value, pos-1)
+		s.Require().NoError(err)
+		var buf bytes.Buffer
+		s.Require().NoError(rescorePair(&buf, seq1, seq2, res, scorer, aligner, scoring))
+		return buf.String()
+	}
+
+	// TT до -pos и GG в soft clip оцениваются как концевые gap: 8 совпадений и 4 gap
+	global := NewSequenceAligner(cfg, scorer)
+	s.Equal("Score: 0\nAligner score: 0 (alignment is optimal)\n", rescore("8M2S", 3, global, scoring))
+	s.Equal("Score: -6\nAligner score: 0 (better alignment exists)\n", rescore("2S8M", 1, global, scoring))
+
+	// в локальном режиме символы вне выравнивания не учитываются
+	local := &SequenceAlignerConfig{GapPenalty: -2, AllowLocal: true}
+	localScoring := &ScoringInfo{GapOpen: -2, GapExtend: -2, Local: true}
+	s.Equal("Score: 8\nAligner score: 8 (alignment is optimal)\n",
+		rescore("8M2S", 3, NewSequenceAligner(local, scorer), localScoring))
+}
+
+func (s *RescoreTestSuite) TestWithEndGaps() {
+	res := (&AlignResult{Aligned1: "CG", Aligned2: "C-", Start1: 1, End1: 3, Start2: 2, End2: 3, Len1: 4, Len2: 4}).
+		withEndGaps("ACGT", "TTCA")
+	s.Equal("A--CGT-", res.Aligned1)
+	s.Equal("-TTC--A", res.Aligned2)
+	s.Equal([]int{0, 4, 4, 0, 4, 4}, []int{res.Start1, res.End1, res.Len1, res.Start2, res.End2, res.Len2})
+}
+
+func (s *RescoreTestSuite) TestWriteRescore() {
+	var buf bytes.Buffer
+	s.Require().NoError(writeRescore(&buf, 3, 5))
+	s.Equal("Score: 3\nAligner score: 5 (better alignment exists)\n", buf.String())
+
+	buf.Reset()
+	s.Require().NoError(writeRescore(&buf, 5, 5))
+	s.Equal("Score: 5\nAligner score: 5 (alignment is optimal)\n", buf.String())
+
+	buf.Reset()
+	s.Require().NoError(writeRescore(&buf, 8, 0))
+	s.NotContains(buf.String(), "optimal")
+}
+
+func TestRescoreSuite(t *testing.T) {
+	suite.Run(t, new(RescoreTestSuite))
+}
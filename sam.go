@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+const (
+	samVersion = "1.6"
+	// samMapqUnavailable значение MAPQ, означающее, что качество картирования не вычислялось
+	samMapqUnavailable = 255
+	// samFlagUnmapped флаг невыровненного запроса
+	samFlagUnmapped = 4
+	// samMissing значение пропущенного текстового поля SAM
+	samMissing = "*"
+)
+
+// samFormatter выводит выравнивания запросов на референсы в формате SAM.
+// Концевые gap выравнивания переносятся в soft clip и позицию начала на референсе.
+type samFormatter struct {
+	cfg *FormatConfig
+}
+
+func (f *samFormatter) Format(w io.Writer, records []*AlignmentRecord) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "@HD\tVN:%s\tSO:unsorted\n", samVersion)
+	seen := make(map[string]bool)
+	for _, rec := range records {
+		name := recordName(rec.Seq1, "seq1")
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		fmt.Fprintf(bw, "@SQ\tSN:%s\tLN:%d\n", name, rec.Len1)
+	}
+	fmt.Fprintf(bw, "@PG\tID:%s\tPN:%s\n", programName, programName)
+
+	for _, rec := range records {
+		f.writeRecord(bw, rec)
+	}
+	return bw.Flush()
+}
+
+func (f *samFormatter) writeRecord(w io.Writer, rec *AlignmentRecord) {
+	res := rec.trimGaps()
+
+	qual := rec.Seq2.Quality
+	if qual == "" {
+		qual = samMissing
+	}
+	seq := rec.Seq2.Value
+	if seq == "" {
+		seq = samMissing
+	}
+
+	if res.Aligned1 == "" {
+		fmt.Fprintf(w, "%s\t%d\t%s\t0\t0\t%s\t*\t0\t0\t%s\t%s\n",
+			recordName(rec.Seq2, "seq2"), samFlagUnmapped, samMissing, samMissing, seq, qual)
+		return
+	}
+
+	fmt.Fprintf(w, "%s\t0\t%s\t%d\t%d\t%s\t*\t0\t0\t%s\t%s\tAS:i:%d\tNM:i:%d\n",
+		recordName(rec.Seq2, "seq2"), recordName(rec.Seq1, "seq1"), res.Start1+1, samMapqUnavailable,
+		NewCigar(res, f.cfg.ExtendedCigar), seq, qual, res.Score, res.editDistance())
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SamTestSuite struct {
+	suite.Suite
+}
+
+func (s *SamTestSuite) format(records ...*AlignmentRecord) string {
+	var buf bytes.Buffer
+	s.Require().NoError((&samFormatter{cfg: &FormatConfig{}}).Format(&buf, records))
+	return buf.String()
+}
+
+func (s *SamTestSuite) TestFormat() {
+	ref := &Sequence{Description: "chr1 reference", Value: "ACGTACGT"}
+	query := &Sequence{Description: "read1", Value: "TTACGTAC", Quality: "IIIIIIII"}
+	aligner := NewSequenceAligner(&SequenceAlignerConfig{GapPenalty: -1}, NewDefaultAdapter(1, -1))
+
+	out := s.format(&AlignmentRecord{
+		Seq1:        ref,
+		Seq2:        query,
+		AlignResult: alignDetailed(aligner, ref.Value, query.Value),
+	})
+	s.Equal("@HD\tVN:1.6\tSO:unsorted\n"+
+		"@SQ\tSN:chr1\tLN:8\n"+
+		"@PG\tID:seq-aligner\tPN:seq-aligner\n"+
+		"read1\t0\tchr1\t1\t255\t2S6M\t*\t0\t0\tTTACGTAC\tIIIIIIII\tAS:i:6\tNM:i:0\n", out)
+}
+
+func (s *SamTestSuite) TestTrimGaps() {
+	res := (&AlignResult{
+		Aligned1: "--AC-GTA-",
+		Aligned2: "TTACCGT-G",
+		Start1:   1, End1: 6,
+		Start2: 0, End2: 8,
+		Len1: 6, Len2: 8,
+	}).trimGaps()
+	s.Equal("AC-GT", res.Aligned1)
+	s.Equal("ACCGT", res.Aligned2)
+	s.Equal([]int{1, 5, 2, 7}, []int{res.Start1, res.End1, res.Start2, res.End2})
+	s.Equal("2S2M1I2M1S", NewCigar(res, false).String())
+	s.Equal(1, res.editDistance())
+}
+
+func (s *SamTestSuite) TestUnmapped() {
+	out := s.format(&AlignmentRecord{
+		Seq1:        &Sequence{Value: "ACGT"},
+		Seq2:        &Sequence{Value: "TT"},
+		AlignResult: &AlignResult{Len1: 4, Len2: 2},
+	})
+	s.Contains(out, "@SQ\tSN:seq1\tLN:4\n")
+	s.Contains(out, "seq2\t4\t*\t0\t0\t*\t*\t0\t0\tTT\t*\n")
+}
+
+func TestSamSuite(t *testing.T) {
+	suite.Run(t, new(SamTestSuite))
+}
@@ -0,0 +1,483 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Ошибки выбора записей для выравнивания
+var (
+	ErrNoRecordMatches   = errors.New("no record matches")
+	ErrManyRecordsMatch  = errors.New("several records match")
+	ErrBadRecordIndex    = errors.New("record index out of range")
+	ErrNotEnoughRecords  = errors.New("not enough records")
+	ErrUnusedInputFile   = errors.New("input file is not used by --seq1 and --seq2")
+	ErrMissingInputFiles = errors.New("no input file for sequence")
+)
+
+// recordIndexPrefix начало селектора записи по порядковому номеру
+const recordIndexPrefix = "#"
+
+// maxListedRecords максимальное число записей, перечисляемых в ошибке
+const maxListedRecords = 5
+
+// SequenceSpec описывает, какую запись и из какого файла взять для выравнивания
+type SequenceSpec struct {
+	// File имя файла, пустое имя означает файл из аргументов командной строки
+	File string
+	// Selector идентификатор записи, "#N" для N-й записи (с 1)
+	// или пустая строка, если подходит первая невыбранная запись.
+	// Может заканчиваться участком записи ":start-end" (с 1, включительно).
+	Selector string
+}
+
+// ParseSequenceSpec разбирает строку вида file[:selector].
+// Имя файла может содержать ':' (например, C:\data\ref.fa), поэтому файлом считается
+// самое длинное начало строки до ':', которое является существующим файлом.
+// Если такого нет, то строка делится по первому ':'.
+func ParseSequenceSpec(s string) SequenceSpec {
+	if isRegularFile(s) {
+		return SequenceSpec{File: s}
+	}
+	for i := strings.LastIndexByte(s, ':'); i > 0; i = strings.LastIndexByte(s[:i], ':') {
+		if isRegularFile(s[:i]) {
+			return SequenceSpec{File: s[:i], Selector: s[i+1:]}
+		}
+	}
+
+	file, selector := s, ""
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		file, selector = s[:i], s[i+1:]
+	}
+	return SequenceSpec{File: file, Selector: selector}
+}
+
+// isRegularFile сообщает, что name — существующий файл, а не каталог
+func isRegularFile(name string) bool {
+	info, err := os.Stat(name)
+	return err == nil && !info.IsDir()
+}
+
+// SelectConfig набор параметров выбора записей для выравнивания
+type SelectConfig struct {
+	// Seq1 и Seq2 описания записей в виде file[:selector], пустая строка означает первую подходящую запись
+	Seq1 string
+	Seq2 string
+	// Pattern отбирает записи без селектора по описанию, может быть nil
+	Pattern *regexp.Regexp
+}
+
+// resolveSpecs сопоставляет описаниям записей файлы из аргументов командной строки.
+// Возвращает true, если обе записи берутся из одного файла и не должны совпадать.
+func resolveSpecs(fileNames []string, cfg *SelectConfig) ([]SequenceSpec, bool, error) {
+	specs := []SequenceSpec{ParseSequenceSpec(cfg.Seq1), ParseSequenceSpec(cfg.Seq2)}
+
+	var positional []int
+	for i := range specs {
+		if specs[i].File == "" {
+			positional = append(positional, i)
+		}
+	}
+
+	switch {
+	case len(fileNames) > 2:
+		return nil, false, ErrWrongNumberOfFiles
+	case len(fileNames) == 0 && len(positional) > 0:
+		return nil, false, ErrMissingInputFiles
+	case len(fileNames) > len(positional):
+		return nil, false, ErrUnusedInputFile
+	case len(fileNames) == 1:
+		for _, i := range positional {
+			specs[i].File = fileNames[0]
+		}
+	case len(fileNames) == 2:
+		if fileNames[0] == stdinName && fileNames[1] == stdinName {
+			return nil, false, ErrStdinTwice
+		}
+		specs[0].File, specs[1].File = fileNames[0], fileNames[1]
+	}
+
+	return specs, len(fileNames) != 2 && specs[0].File == specs[1].File, nil
+}
+
+// recordSources открытые файлы записей по именам
+type recordSources struct {
+	cfg     *ReaderConfig
+	sources map[string]*recordSource
+}
+
+func newRecordSources(cfg *ReaderConfig) *recordSources {
+	return &recordSources{
+		cfg:     cfg,
+		sources: make(map[string]*recordSource),
+	}
+}
+
+// get возвращает открытый файл filename, открывая его при первом обращении
+func (s *recordSources) get(filename string) (*recordSource, error) {
+	if src, ok := s.sources[filename]; ok {
+		return src, nil
+	}
+	src, err := openRecordSource(filename, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.sources[filename] = src
+	return src, nil
+}
+
+// Close закрывает все открытые файлы
+func (s *recordSources) Close() {
+	for _, src := range s.sources {
+		src.Close()
+	}
+}
+
+// selectSequences читает записи, описанные specs.
+// Сначала выбираются записи с селектором, затем остальные получают первые подходящие под pattern записи.
+// Если shared, то все записи берутся из одного файла и одна запись не выбирается дважды.
+func selectSequences(specs []SequenceSpec, shared bool, pattern *regexp.Regexp, cfg *ReaderConfig) ([]*Sequence, error) {
+	sources := newRecordSources(cfg)
+	defer sources.Close()
+
+	res := make([]*Sequence, len(specs))
+	taken := make(map[int]bool)
+	var implicit []int
+	for i, spec := range specs {
+		if spec.Selector == "" {
+			implicit = append(implicit, i)
+			continue
+		}
+
+		// участки записей могут пересекаться, поэтому не считаются выбранными
+		if name, region, ok := ParseRegion(spec.Selector); ok {
+			seq, err := fetchRegion(spec, name, region, sources)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = seq
+			continue
+		}
+
+		src, err := sources.get(spec.File)
+		if err != nil {
+			return nil, err
+		}
+		index, err := findRecord(src, spec.Selector)
+		if err != nil {
+			return nil, err
+		}
+		if shared && taken[index] {
+			return nil, errors.Errorf("%s:%s: record is selected twice", spec.File, spec.Selector)
+		}
+		res[i] = src.records[index]
+		taken[index] = true
+	}
+
+	groups := make([][]int, 0, len(implicit))
+	if shared && len(implicit) > 0 {
+		groups = append(groups, implicit)
+	} else {
+		for _, i := range implicit {
+			groups = append(groups, []int{i})
+		}
+	}
+	for _, group := range groups {
+		filename := specs[group[0]].File
+		src, err := sources.get(filename)
+		if err != nil {
+			return nil, err
+		}
+		if !shared {
+			taken = nil
+		}
+		indexes, err := pickRecords(src, len(group), pattern, taken)
+		if err != nil {
+			return nil, err
+		}
+		for k, i := range group {
+			res[i] = src.records[indexes[k]]
+		}
+	}
+
+	return res, nil
+}
+
+// findRecord возвращает номер (с 0) записи, выбранной селектором.
+// Ошибки выбора дополняются именем файла и селектором, ошибки чтения уже содержат имя файла.
+func findRecord(src *recordSource, selector string) (int, error) {
+	if strings.HasPrefix(selector, recordIndexPrefix) {
+		n, err := strconv.Atoi(selector[len(recordIndexPrefix):])
+		if err != nil || n < 1 {
+			return 0, errors.Wrapf(ErrBadRecordIndex, "%s:%s", src.filename, selector)
+		}
+		if err := src.readUntil(n - 1); err != nil {
+			return 0, err
+		}
+		if n > len(src.records) {
+			return 0, errors.Wrapf(ErrBadRecordIndex, "%s:%s: file has %d records", src.filename, selector, len(src.records))
+		}
+		return n - 1, nil
+	}
+
+	records, err := src.all()
+	if err != nil {
+		return 0, err
+	}
+	var matches []int
+	for i, seq := range records {
+		if seq.Description == selector || sequenceID(seq) == selector {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		return 0, errors.Wrapf(ErrNoRecordMatches, "%s:%s", src.filename, selector)
+	}
+	if len(matches) > 1 {
+		return 0, errors.Wrapf(ErrManyRecordsMatch, "%s:%s: %s", src.filename, selector, listRecords(records, matches))
+	}
+	return matches[0], nil
+}
+
+// fetchRegion возвращает участок region записи name файла spec.File.
+// Если у файла есть индекс .fai, то участок читается напрямую, иначе запись ищется последовательным чтением.
+func fetchRegion(spec SequenceSpec, name string, region Region, sources *recordSources) (*Sequence, error) {
+	if seq, err := fetchIndexedRegion(spec.File, name, region); seq != nil || err != nil {
+		return seq, errors.Wrapf(err, "%s:%s", spec.File, spec.Selector)
+	}
+
+	src, err := sources.get(spec.File)
+	if err != nil {
+		return nil, err
+	}
+	index, err := findRecord(src, name)
+	if err != nil {
+		return nil, err
+	}
+	seq := src.records[index]
+	start, end, err := region.bounds(int64(len(seq.Value)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s:%s", spec.File, spec.Selector)
+	}
+
+	res := &Sequence{
+		Description: regionDescription(sequenceID(seq), start, end),
+		Value:       seq.Value[start:end],
+	}
+	if seq.Quality != "" {
+		res.Quality = seq.Quality[start:end]
+	}
+	return res, nil
+}
+
+// fetchIndexedRegion читает участок записи с помощью индекса .fai.
+// Возвращает nil без ошибки, если индекса нет или файл сжат.
+func fetchIndexedRegion(filename, selector string, region Region) (*Sequence, error) {
+	if filename == stdinName {
+		return nil, nil
+	}
+	idxFile, err := os.Open(filename + faiSuffix)
+	if err != nil {
+		return nil, nil
+	}
+	defer idxFile.Close()
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if compressed, err := isCompressed(f); err != nil || compressed {
+		return nil, err
+	}
+
+	idx, err := ReadFastaIndex(idxFile)
+	if err != nil {
+		return nil, errors.Wrap(err, filename+faiSuffix)
+	}
+	entry, err := idx.Lookup(selector)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := region.bounds(entry.Length)
+	if err != nil {
+		return nil, err
+	}
+	value, err := entry.Fetch(f, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sequence{
+		Description: regionDescription(entry.Name, start, end),
+		Value:       value,
+	}, nil
+}
+
+// regionDescription возвращает описание участка [start, end) (с 0) в виде name:start-end (с 1)
+func regionDescription(name string, start, end int64) string {
+	return fmt.Sprintf("%s:%d-%d", name, start+1, end)
+}
+
+// pickRecords возвращает номера (с 0) первых count записей, не входящих в taken.
+// Если задан pattern, то записи отбираются по описанию и подходящих должно быть ровно count.
+// Ошибки выбора дополняются именем файла, ошибки чтения уже содержат его.
+func pickRecords(src *recordSource, count int, pattern *regexp.Regexp, taken map[int]bool) ([]int, error) {
+	if pattern == nil {
+		var res []int
+		for i := 0; len(res) < count; i++ {
+			if err := src.readUntil(i); err != nil {
+				return nil, err
+			}
+			if i >= len(src.records) {
+				return nil, errors.Wrapf(ErrNotEnoughRecords, "%s: expected %d, found %d", src.filename, count, len(res))
+			}
+			if !taken[i] {
+				res = append(res, i)
+			}
+		}
+		return res, nil
+	}
+
+	records, err := src.all()
+	if err != nil {
+		return nil, err
+	}
+	var matches []int
+	for i, seq := range records {
+		if !taken[i] && pattern.MatchString(seq.Description) {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, errors.Wrapf(ErrNoRecordMatches, "%s: pattern %q", src.filename, pattern)
+	}
+	if len(matches) < count {
+		return nil, errors.Wrapf(ErrNotEnoughRecords, "%s: pattern %q matches %d records, expected %d: %s",
+			src.filename, pattern, len(matches), count, listRecords(records, matches))
+	}
+	if len(matches) > count {
+		return nil, errors.Wrapf(ErrManyRecordsMatch, "%s: pattern %q matches %d records, expected %d: %s",
+			src.filename, pattern, len(matches), count, listRecords(records, matches))
+	}
+	return matches, nil
+}
+
+// sequenceID возвращает идентификатор записи — первое слово описания
+func sequenceID(seq *Sequence) string {
+	fields := strings.Fields(seq.Description)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// listRecords перечисляет номера и идентификаторы записей для сообщений об ошибках
+func listRecords(records []*Sequence, indexes []int) string {
+	parts := make([]string, 0, MinInt(len(indexes), maxListedRecords)+1)
+	for k, i := range indexes {
+		if k == maxListedRecords {
+			parts = append(parts, "...")
+			break
+		}
+		parts = append(parts, fmt.Sprintf("#%d %s", i+1, sequenceID(records[i])))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// loadSequences читает две записи для выравнивания из файлов fileNames в соответствии с selectCfg
+func loadSequences(fileNames []string, selectCfg *SelectConfig, cfg *ReaderConfig) ([]*Sequence, error) {
+	specs, shared, err := resolveSpecs(fileNames, selectCfg)
+	if err != nil {
+		return nil, err
+	}
+	return selectSequences(specs, shared, selectCfg.Pattern, cfg)
+}
+
+// candidate запись-кандидат пакетного выравнивания
+type candidate struct {
+	seq *Sequence
+	// index номер (с 0) записи в файле или -1 для участка записи
+	index int
+}
+
+// selectCandidates возвращает записи, описанные spec: одну запись для селектора
+// или все подходящие под pattern записи файла
+func selectCandidates(spec SequenceSpec, pattern *regexp.Regexp, sources *recordSources) ([]candidate, error) {
+	if name, region, ok := ParseRegion(spec.Selector); ok {
+		seq, err := fetchRegion(spec, name, region, sources)
+		if err != nil {
+			return nil, err
+		}
+		return []candidate{{seq: seq, index: -1}}, nil
+	}
+
+	src, err := sources.get(spec.File)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Selector != "" {
+		index, err := findRecord(src, spec.Selector)
+		if err != nil {
+			return nil, err
+		}
+		return []candidate{{seq: src.records[index], index: index}}, nil
+	}
+
+	records, err := src.all()
+	if err != nil {
+		return nil, err
+	}
+	var res []candidate
+	for i, seq := range records {
+		if pattern == nil || pattern.MatchString(seq.Description) {
+			res = append(res, candidate{seq: seq, index: i})
+		}
+	}
+	if len(res) == 0 {
+		return nil, errors.Wrap(ErrNoRecordMatches, spec.File)
+	}
+	return res, nil
+}
+
+// loadPairs читает пары записей для пакетного выравнивания из файлов fileNames.
+// Записи без селектора заменяются всеми подходящими под шаблон записями файла.
+// Из двух файлов берутся все пары записей первого и второго файла,
+// из одного файла — все пары различных записей без учёта порядка.
+func loadPairs(fileNames []string, selectCfg *SelectConfig, cfg *ReaderConfig) ([][2]*Sequence, error) {
+	specs, shared, err := resolveSpecs(fileNames, selectCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := newRecordSources(cfg)
+	defer sources.Close()
+
+	var sides [2][]candidate
+	for i, spec := range specs {
+		if sides[i], err = selectCandidates(spec, selectCfg.Pattern, sources); err != nil {
+			return nil, err
+		}
+	}
+
+	// при выборе всех записей одного файла каждая пара выравнивается один раз
+	unordered := shared && specs[0].Selector == "" && specs[1].Selector == ""
+	var res [][2]*Sequence
+	for _, a := range sides[0] {
+		for _, b := range sides[1] {
+			if shared && a.index >= 0 && a.index == b.index || unordered && a.index > b.index {
+				continue
+			}
+			res = append(res, [2]*Sequence{a.seq, b.seq})
+		}
+	}
+	if len(res) == 0 {
+		return nil, errors.Wrap(ErrNotEnoughRecords, "no pairs to align")
+	}
+	return res, nil
+}
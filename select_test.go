@@ -0,0 +1,194 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type SelectTestSuite struct {
+	suite.Suite
+}
+
+func (s *SelectTestSuite) ids(seqs []*Sequence) []string {
+	res := make([]string, len(seqs))
+	for i, seq := range seqs {
+		res[i] = sequenceID(seq)
+	}
+	return res
+}
+
+func (s *SelectTestSuite) TestLoadSequences() {
+	const file = "testdata/select.fa"
+	for _, c := range []struct {
+		files   []string
+		cfg     SelectConfig
+		expIDs  []string
+		expErr  error
+		comment string
+	}{
+		{
+			files:   []string{file},
+			expIDs:  []string{"chr1", "chr2"},
+			comment: "первые две записи, как и раньше",
+		},
+		{
+			files:   []string{file, file},
+			expIDs:  []string{"chr1", "chr1"},
+			comment: "первые записи каждого из файлов",
+		},
+		{
+			files:   []string{file},
+			cfg:     SelectConfig{Seq1: ":chrM"},
+			expIDs:  []string{"chrM", "chr1"},
+			comment: "вторая запись — первая из невыбранных",
+		},
+		{
+			files:   []string{file},
+			cfg:     SelectConfig{Seq1: ":chr1", Seq2: ":#1"},
+			comment: "одна и та же запись дважды",
+		},
+		{
+			cfg:     SelectConfig{Seq1: file + ":#4", Seq2: file + ":chr2 second chromosome"},
+			expIDs:  []string{"chrM_alt", "chr2"},
+			comment: "файлы и записи заданы явно",
+		},
+		{
+			files:   []string{file},
+			cfg:     SelectConfig{Pattern: regexp.MustCompile("mitochondrion")},
+			expIDs:  []string{"chrM", "chrM_alt"},
+			comment: "ровно две записи подходят под шаблон",
+		},
+		{
+			files:   []string{file, file},
+			cfg:     SelectConfig{Seq1: ":chr2", Pattern: regexp.MustCompile("alternative")},
+			expIDs:  []string{"chr2", "chrM_alt"},
+			comment: "шаблон применяется только к записям без селектора",
+		},
+		{
+			files:  []string{file},
+			cfg:    SelectConfig{Pattern: regexp.MustCompile("chromosome|mitochondrion")},
+			expErr: ErrManyRecordsMatch,
+		},
+		{
+			files:  []string{file},
+			cfg:    SelectConfig{Pattern: regexp.MustCompile("plasmid")},
+			expErr: ErrNoRecordMatches,
+		},
+		{
+			files:  []string{file},
+			cfg:    SelectConfig{Seq1: ":chrX"},
+			expErr: ErrNoRecordMatches,
+		},
+		{
+			files:  []string{file},
+			cfg:    SelectConfig{Seq1: ":#5"},
+			expErr: ErrBadRecordIndex,
+		},
+		{
+			cfg:    SelectConfig{Seq1: file + ":chr1"},
+			expErr: ErrMissingInputFiles,
+		},
+		{
+			files:  []string{file},
+			cfg:    SelectConfig{Seq1: file, Seq2: file},
+			expErr: ErrUnusedInputFile,
+		},
+	} {
+		seqs, err := loadSequences(c.files, &c.cfg, nil)
+		if c.expIDs == nil {
+			s.Error(err, c.comment)
+			if c.expErr != nil {
+				s.Equal(c.expErr, errors.Cause(err), c.comment)
+			}
+			continue
+		}
+		s.Require().NoError(err, c.comment)
+		s.Equal(c.expIDs, s.ids(seqs), c.comment)
+	}
+}
+
+func (s *SelectTestSuite) TestErrorMessages() {
+	dir := s.T().TempDir()
+	file := filepath.Join(dir, "bad.fa")
+	s.Require().NoError(os.WriteFile(file, []byte(">a\nAC GT\n>b\nAC\n"), 0o644))
+
+	// имя файла и селектор встречаются в сообщении ровно один раз
+	for _, c := range []struct {
+		cfg    SelectConfig
+		mode   FastaMode
+		prefix string
+	}{
+		{cfg: SelectConfig{}, mode: FastaStrict, prefix: file + ": fasta parser: whitespace"},
+		{cfg: SelectConfig{Seq1: file + ":b"}, mode: FastaStrict, prefix: file + ": fasta parser: whitespace"},
+		{cfg: SelectConfig{Seq1: file + ":#5"}, prefix: file + ":#5: file has 2 records"},
+		{cfg: SelectConfig{Seq1: file + ":zz"}, prefix: file + ":zz: no record"},
+		{cfg: SelectConfig{Seq1: file + ":a:10-20"}, prefix: file + ":a:10-20: sequence has 4"},
+	} {
+		_, err := loadSequences([]string{file}, &c.cfg, &ReaderConfig{FastaMode: c.mode})
+		s.Require().Error(err, c.prefix)
+		s.True(strings.HasPrefix(err.Error(), c.prefix), err.Error())
+		s.Equal(1, strings.Count(err.Error(), file), err.Error())
+	}
+}
+
+func (s *SelectTestSuite) TestParseSequenceSpec() {
+	dir := s.T().TempDir()
+	file := filepath.Join(dir, "run:1", "ref.fa")
+	s.Require().NoError(os.MkdirAll(filepath.Dir(file), 0o755))
+	s.Require().NoError(os.WriteFile(file, []byte(">a\nACGT\n>b\nAC\n"), 0o644))
+
+	for _, c := range []struct {
+		spec string
+		exp  SequenceSpec
+	}{
+		{spec: file, exp: SequenceSpec{File: file}},
+		{spec: file + ":#2", exp: SequenceSpec{File: file, Selector: "#2"}},
+		{spec: file + ":a:2-3", exp: SequenceSpec{File: file, Selector: "a:2-3"}},
+		{spec: ":chr1", exp: SequenceSpec{Selector: "chr1"}},
+		{spec: "missing.fa:chr1:1-2", exp: SequenceSpec{File: "missing.fa", Selector: "chr1:1-2"}},
+	} {
+		s.Equal(c.exp, ParseSequenceSpec(c.spec), c.spec)
+	}
+
+	seqs, err := loadSequences(nil, &SelectConfig{Seq1: file + ":#2", Seq2: file + ":a:2-3"}, nil)
+	s.Require().NoError(err)
+	s.Equal([]string{"AC", "CG"}, []string{seqs[0].Value, seqs[1].Value})
+}
+
+func (s *SelectTestSuite) TestLoadPairs() {
+	const file = "testdata/select.fa"
+	pairIDs := func(pairs [][2]*Sequence) []string {
+		res := make([]string, len(pairs))
+		for i, pair := range pairs {
+			res[i] = sequenceID(pair[0]) + "/" + sequenceID(pair[1])
+		}
+		return res
+	}
+
+	pairs, err := loadPairs([]string{file}, &SelectConfig{Pattern: regexp.MustCompile("chromosome|alternative")}, nil)
+	s.Require().NoError(err)
+	s.Equal([]string{"chr1/chr2", "chr1/chrM_alt", "chr2/chrM_alt"}, pairIDs(pairs), "все пары записей одного файла")
+
+	pairs, err = loadPairs([]string{file}, &SelectConfig{Seq1: ":chrM"}, nil)
+	s.Require().NoError(err)
+	s.Equal([]string{"chrM/chr1", "chrM/chr2", "chrM/chrM_alt"}, pairIDs(pairs), "одна запись против остальных")
+
+	pairs, err = loadPairs([]string{file, file}, &SelectConfig{Seq1: ":chr1:1-4", Pattern: regexp.MustCompile("chrM")}, nil)
+	s.Require().NoError(err)
+	s.Equal([]string{"chr1:1-4/chrM", "chr1:1-4/chrM_alt"}, pairIDs(pairs), "участок записи против записей второго файла")
+
+	_, err = loadPairs([]string{file}, &SelectConfig{Pattern: regexp.MustCompile("plasmid")}, nil)
+	s.Equal(ErrNoRecordMatches, errors.Cause(err))
+	_, err = loadPairs([]string{file}, &SelectConfig{Pattern: regexp.MustCompile("first")}, nil)
+	s.Equal(ErrNotEnoughRecords, errors.Cause(err))
+}
+
+func TestSelectSuite(t *testing.T) {
+	suite.Run(t, new(SelectTestSuite))
+}
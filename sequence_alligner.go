@@ -1,12 +1,11 @@
 package main
 
-import (
-	"strings"
-)
-
 // SequenceAligner вспомогательный объект для глобального выравнивания
 type SequenceAligner struct {
 	sequenceAlignerBase
+
+	// dump, если не nil, получает построенную матрицу
+	dump *MatrixDump
 }
 
 // NewSequenceAligner возвращает новый объект SequenceAligner
@@ -24,10 +23,31 @@ func NewSequenceAligner(cfg *SequenceAlignerConfig, scorer Scorer) *SequenceAlig
 
 // Align производит оптимальное глобальное выравнивание двух последовательностей
 func (a *SequenceAligner) Align(str1, str2 string) (string, string, int) {
-	actions, score := a.findActions(str1, str2)
-	alignedStr1, alignedStr2 := &strings.Builder{}, &strings.Builder{}
+	res := a.alignStrings(str1, str2, nil, nil, a.align)
+	return res.Aligned1, res.Aligned2, res.Score
+}
+
+// AlignDetailed производит выравнивание и возвращает его вместе с координатами.
+// Оценка совмещения i-го и j-го символов умножается на w1[i]*w2[j], nil вместо весов означает, что все веса равны 1.
+// Если число весов не совпадает с числом символов, возвращается ErrWeightsLength.
+func (a *SequenceAligner) AlignDetailed(str1, str2 string, w1, w2 []float64) (*AlignResult, error) {
+	return a.alignWeighted(str1, str2, w1, w2, a.align)
+}
+
+// DumpMatrices выравнивает str1 и str2 и возвращает построенную матрицу с отмеченным путём обратного прохода
+func (a *SequenceAligner) DumpMatrices(str1, str2 string) *MatrixDump {
+	a.dump = &MatrixDump{Seq1: []rune(str1), Seq2: []rune(str2)}
+	defer func() { a.dump = nil }()
+
+	a.dump.markPath(a.alignStrings(str1, str2, nil, nil, a.align))
+	return a.dump
+}
+
+func (a *SequenceAligner) align(n, m int, score scoreFunc) *Alignment {
+	actions, res := a.findActions(n, m, score)
 
 	i, j := len(actions)-1, len(actions[0])-1
+	path := make([]Operation, 0, i+j)
 
 WriteCycle:
 	for {
@@ -35,36 +55,38 @@ WriteCycle:
 			break WriteCycle
 		}
 
-		switch actions[i][j] {
+		act := actions[i][j]
+		switch act {
 		case letterAction:
-			alignedStr1.WriteByte(str1[i-1])
-			alignedStr2.WriteByte(str2[j-1])
 			i--
 			j--
 		case firstGapAction:
-			alignedStr1.WriteByte(gapByte)
-			alignedStr2.WriteByte(str2[j-1])
 			j--
 		case secondGapAction:
-			alignedStr1.WriteByte(str1[i-1])
-			alignedStr2.WriteByte(gapByte)
 			i--
 		case zeroAction:
 			break WriteCycle
 		}
+		path = append(path, Operation(act))
 	}
+	reverseOperations(path)
 
-	return Reverse(alignedStr1.String()), Reverse(alignedStr2.String()), score
+	return &Alignment{
+		Operations: path,
+		Score:      res,
+		Start1:     i,
+		Start2:     j,
+	}
 }
 
-func (a *SequenceAligner) findActions(str1, str2 string) ([][]action, int) {
-	dp, actions := a.buildBaseMatrices(len(str1)+1, len(str2)+1)
-	for i := 1; i <= len(str1); i++ {
-		for j := 1; j <= len(str2); j++ {
+func (a *SequenceAligner) findActions(n, m int, score scoreFunc) ([][]action, int) {
+	dp, actions := a.buildBaseMatrices(n+1, m+1)
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
 			val, indx := MaxOfThreeInt(
-				dp[i-1][j-1]+a.scorer.Score(str1[i-1], str2[j-1]), // i-1 и j-1 потому что с 1
-				dp[i][j-1]+a.getGapPenalty(i, len(str1)),
-				dp[i-1][j]+a.getGapPenalty(j, len(str2)),
+				dp[i-1][j-1]+score(i-1, j-1), // i-1 и j-1 потому что с 1
+				dp[i][j-1]+a.getGapPenalty(i, n),
+				dp[i-1][j]+a.getGapPenalty(j, m),
 			)
 			if a.allowLocal && val < 0 {
 				val = 0
@@ -75,8 +97,11 @@ func (a *SequenceAligner) findActions(str1, str2 string) ([][]action, int) {
 			actions[i][j] = action(indx)
 		}
 	}
+	if a.dump != nil {
+		a.dump.Matrices = append(a.dump.Matrices, newDPMatrix("dp", dp, linearTraceback(actions)))
+	}
 
-	maxI, maxJ := len(str1), len(str2)
+	maxI, maxJ := n, m
 	if a.allowLocal {
 		maxVal := -int(^uint(0)>>1) - 1 // minimal int value
 		for i := 0; i < len(dp); i++ {
@@ -1,6 +1,14 @@
 package main
 
-const gapByte = byte('-')
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	gapByte = byte('-')
+	gapRune = rune(gapByte)
+)
 
 type action byte
 
@@ -19,12 +27,93 @@ type SequenceAlignerConfig struct {
 	GapPenalty      int
 }
 
+// scoreFunc оценивает совмещение i-го символа первой последовательности с j-м символом второй (с 0)
+type scoreFunc func(i, j int) int
+
+// Operation операция выравнивания над очередными символами последовательностей
+type Operation byte
+
+const (
+	// OpMatch совмещение символов обеих последовательностей (совпадение или замена)
+	OpMatch = Operation(letterAction)
+	// OpInsert символ второй последовательности напротив gap в первой
+	OpInsert = Operation(firstGapAction)
+	// OpDelete символ первой последовательности напротив gap во второй
+	OpDelete = Operation(secondGapAction)
+)
+
+// Alignment результат выравнивания в виде списка операций
+type Alignment struct {
+	// Operations операции от начала выравнивания к концу
+	Operations []Operation
+	Score      int
+	// Start1 и Start2 позиции (с 0) начала выравнивания в последовательностях,
+	// отличны от 0 только при локальном выравнивании
+	Start1 int
+	Start2 int
+}
+
+// AlignResult выровненные строки вместе с координатами выравнивания в исходных строках
+type AlignResult struct {
+	Aligned1 string
+	Aligned2 string
+	Score    int
+	// Start1 и Start2 позиции (с 0, в символах) начала выравнивания,
+	// End1 и End2 позиции сразу за его концом
+	Start1 int
+	End1   int
+	Start2 int
+	End2   int
+	// Len1 и Len2 длины исходных строк в символах
+	Len1 int
+	Len2 int
+}
+
+// newAlignResult дополняет выровненные строки координатами по операциям res
+func newAlignResult(res *Alignment, aligned1, aligned2 string, len1, len2 int) *AlignResult {
+	r := &AlignResult{
+		Aligned1: aligned1,
+		Aligned2: aligned2,
+		Score:    res.Score,
+		Start1:   res.Start1,
+		End1:     res.Start1,
+		Start2:   res.Start2,
+		End2:     res.Start2,
+		Len1:     len1,
+		Len2:     len2,
+	}
+	for _, op := range res.Operations {
+		if op != OpInsert {
+			r.End1++
+		}
+		if op != OpDelete {
+			r.End2++
+		}
+	}
+	return r
+}
+
+// alignKernel ядро выравнивания последовательностей длин n и m
+type alignKernel func(n, m int, score scoreFunc) *Alignment
+
 type sequenceAlignerBase struct {
 	allowLocal      bool
 	gapStartPenalty bool
 	gapEndPenalty   bool
 	gapPenalty      int
 	scorer          Scorer
+
+	// weighted означает, что идёт выравнивание с весами и оценки хранятся с фиксированной точкой,
+	// умноженными на weightScale, поэтому штрафы за gap тоже нужно умножать
+	weighted bool
+}
+
+// penalty переводит штраф p в масштаб оценок текущего выравнивания
+func (a *sequenceAlignerBase) penalty(p int) int {
+	if a.weighted {
+		return p * weightScale
+	}
+	return p
 }
 
 func (a *sequenceAlignerBase) getGapPenalty(i, max int) int {
@@ -40,5 +129,121 @@ func (a *sequenceAlignerBase) getGapPenalty(i, max int) int {
 		return 0
 	}
 
-	return a.gapPenalty
+	return a.penalty(a.gapPenalty)
+}
+
+// byRunes сообщает, что строки выравниваются по рунам: scorer умеет оценивать руны, а в строках есть многобайтовые символы
+func (a *sequenceAlignerBase) byRunes(str1, str2 string) bool {
+	_, ok := a.scorer.(RuneScorer)
+	return ok && !(isASCII(str1) && isASCII(str2))
+}
+
+// alignWeighted проверяет веса и выравнивает строки с помощью ядра kernel.
+// Веса задаются для каждого выравниваемого символа: для каждой руны при выравнивании по рунам, иначе для каждого байта.
+func (a *sequenceAlignerBase) alignWeighted(str1, str2 string, w1, w2 []float64, kernel alignKernel) (*AlignResult, error) {
+	n, m := len(str1), len(str2)
+	if a.byRunes(str1, str2) {
+		n, m = utf8.RuneCountInString(str1), utf8.RuneCountInString(str2)
+	}
+	if err := checkWeights(w1, n, "first"); err != nil {
+		return nil, err
+	}
+	if err := checkWeights(w2, m, "second"); err != nil {
+		return nil, err
+	}
+	return a.alignStrings(str1, str2, w1, w2, kernel), nil
+}
+
+// alignStrings выравнивает строки с помощью ядра kernel.
+// Если scorer умеет оценивать руны, а в строках есть многобайтовые символы,
+// то строки выравниваются по рунам, иначе — по кодам алфавита, если scorer реализует Encoder, или по байтам.
+// Оценки совмещения символов умножаются на их веса w1 и w2, nil означает единичные веса.
+// Длины весов должны быть проверены alignWeighted.
+func (a *sequenceAlignerBase) alignStrings(str1, str2 string, w1, w2 []float64, kernel alignKernel) *AlignResult {
+	if a.byRunes(str1, str2) {
+		rs := a.scorer.(RuneScorer)
+		runes1, runes2 := []rune(str1), []rune(str2)
+		res := a.runKernel(kernel, len(runes1), len(runes2), func(i, j int) int {
+			return rs.ScoreRune(runes1[i], runes2[j])
+		}, w1, w2)
+		aligned1, aligned2 := ApplyAlignment(res, runes1, runes2, gapRune)
+		return newAlignResult(res, string(aligned1), string(aligned2), len(runes1), len(runes2))
+	}
+
+	enc, ok := a.scorer.(Encoder)
+	if !ok {
+		seq1, seq2 := []byte(str1), []byte(str2)
+		res := a.runKernel(kernel, len(seq1), len(seq2), func(i, j int) int {
+			return a.scorer.Score(seq1[i], seq2[j])
+		}, w1, w2)
+		aligned1, aligned2 := ApplyAlignment(res, seq1, seq2, gapByte)
+		return newAlignResult(res, string(aligned1), string(aligned2), len(seq1), len(seq2))
+	}
+
+	seq1, seq2 := enc.Encode(str1), enc.Encode(str2)
+	res := a.runKernel(kernel, len(seq1), len(seq2), func(i, j int) int {
+		return enc.ScoreCodes(seq1[i], seq2[j])
+	}, w1, w2)
+	aligned1, aligned2 := renderCodes(enc, res, seq1, seq2)
+	return newAlignResult(res, aligned1, aligned2, len(seq1), len(seq2))
+}
+
+// runKernel выравнивает последовательности длин n и m с помощью ядра kernel.
+// Если заданы веса, то оценки совмещения и штрафы считаются с фиксированной точкой,
+// а итоговая оценка округляется до целого.
+func (a *sequenceAlignerBase) runKernel(kernel alignKernel, n, m int, score scoreFunc, w1, w2 []float64) *Alignment {
+	if w1 == nil && w2 == nil {
+		return kernel(n, m, score)
+	}
+
+	a.weighted = true
+	defer func() { a.weighted = false }()
+	res := kernel(n, m, weightedScore(score, w1, w2))
+	res.Score = unscaleScore(res.Score)
+	return res
+}
+
+// renderCodes строит выровненные строки по операциям, восстанавливая символы из кодов алфавита
+func renderCodes(enc Encoder, res *Alignment, seq1, seq2 []byte) (string, string) {
+	alignedStr1, alignedStr2 := &strings.Builder{}, &strings.Builder{}
+	alignedStr1.Grow(len(res.Operations))
+	alignedStr2.Grow(len(res.Operations))
+
+	i, j := res.Start1, res.Start2
+	for _, op := range res.Operations {
+		switch op {
+		case OpMatch:
+			alignedStr1.WriteByte(enc.Decode(seq1[i]))
+			alignedStr2.WriteByte(enc.Decode(seq2[j]))
+			i++
+			j++
+		case OpInsert:
+			alignedStr1.WriteByte(gapByte)
+			alignedStr2.WriteByte(enc.Decode(seq2[j]))
+			j++
+		case OpDelete:
+			alignedStr1.WriteByte(enc.Decode(seq1[i]))
+			alignedStr2.WriteByte(gapByte)
+			i++
+		}
+	}
+
+	return alignedStr1.String(), alignedStr2.String()
+}
+
+// reverseOperations разворачивает список операций, полученный при обратном ходе
+func reverseOperations(ops []Operation) {
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+}
+
+// isASCII проверяет, что строка состоит только из однобайтовых символов
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
 }
@@ -1,9 +1,5 @@
 package main
 
-import (
-	"strings"
-)
-
 // SequenceAlignerExtendConfig набор параметров для конфигурации SequenceAlignerExtend.
 type SequenceAlignerExtendConfig struct {
 	SequenceAlignerConfig
@@ -15,6 +11,9 @@ type SequenceAlignerExtendConfig struct {
 type SequenceAlignerExtend struct {
 	sequenceAlignerBase
 	extendGapPenalty int
+
+	// dump, если не nil, получает построенные матрицы
+	dump *MatrixDump
 }
 
 // NewSequenceAlignerExtend возвращает новый объект SequenceAlignerExtend.
@@ -32,10 +31,32 @@ func NewSequenceAlignerExtend(cfg *SequenceAlignerExtendConfig, scorer Scorer) *
 
 // Align производит оптимальное глобальное выравнивание двух последовательностей
 func (a *SequenceAlignerExtend) Align(str1, str2 string) (string, string, int) {
-	actions, currentAction, score := a.findActions(str1, str2)
-	alignedStr1, alignedStr2 := &strings.Builder{}, &strings.Builder{}
+	res := a.alignStrings(str1, str2, nil, nil, a.align)
+	return res.Aligned1, res.Aligned2, res.Score
+}
+
+// AlignDetailed производит выравнивание и возвращает его вместе с координатами.
+// Оценка совмещения i-го и j-го символов умножается на w1[i]*w2[j], nil вместо весов означает, что все веса равны 1.
+// Если число весов не совпадает с числом символов, возвращается ErrWeightsLength.
+func (a *SequenceAlignerExtend) AlignDetailed(str1, str2 string, w1, w2 []float64) (*AlignResult, error) {
+	return a.alignWeighted(str1, str2, w1, w2, a.align)
+}
+
+// DumpMatrices выравнивает str1 и str2 и возвращает построенные матрицы match, insertion и deletion
+// с отмеченным путём обратного прохода
+func (a *SequenceAlignerExtend) DumpMatrices(str1, str2 string) *MatrixDump {
+	a.dump = &MatrixDump{Seq1: []rune(str1), Seq2: []rune(str2)}
+	defer func() { a.dump = nil }()
 
-	i, j := len(str1), len(str2)
+	a.dump.markPath(a.alignStrings(str1, str2, nil, nil, a.align))
+	return a.dump
+}
+
+func (a *SequenceAlignerExtend) align(n, m int, score scoreFunc) *Alignment {
+	actions, currentAction, res := a.findActions(n, m, score)
+
+	i, j := n, m
+	path := make([]Operation, 0, n+m)
 	for {
 		if i == 0 && j == 0 {
 			break
@@ -44,53 +65,60 @@ func (a *SequenceAlignerExtend) Align(str1, str2 string) (string, string, int) {
 		nextAction := action((actions[i][j] >> (currentAction * 2)) & 0b11)
 		switch currentAction {
 		case letterAction:
-			alignedStr1.WriteByte(str1[i-1])
-			alignedStr2.WriteByte(str2[j-1])
 			i--
 			j--
 		case firstGapAction:
-			alignedStr1.WriteByte(gapByte)
-			alignedStr2.WriteByte(str2[j-1])
 			j--
 		case secondGapAction:
-			alignedStr1.WriteByte(str1[i-1])
-			alignedStr2.WriteByte(gapByte)
 			i--
 		}
+		path = append(path, Operation(currentAction))
 
 		currentAction = nextAction
 	}
+	reverseOperations(path)
 
-	return Reverse(alignedStr1.String()), Reverse(alignedStr2.String()), score
+	return &Alignment{
+		Operations: path,
+		Score:      res,
+	}
 }
 
-func (a *SequenceAlignerExtend) findActions(str1, str2 string) ([][]byte, action, int) {
-	match, insetion, deletion, actions := a.buildExtendMatrices(len(str1)+1, len(str2)+1)
-	for i := 1; i <= len(str1); i++ {
-		for j := 1; j <= len(str2); j++ {
+func (a *SequenceAlignerExtend) findActions(n, m int, score scoreFunc) ([][]byte, action, int) {
+	match, insetion, deletion, actions := a.buildExtendMatrices(n+1, m+1)
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
 			var indexMatch, indexInsertion, indexDeletion int
+			letterScore := score(i-1, j-1)
 			match[i][j], indexMatch = MaxOfThreeInt(
-				match[i-1][j-1]+a.scorer.Score(str1[i-1], str2[j-1]),
-				insetion[i-1][j-1]+a.scorer.Score(str1[i-1], str2[j-1]),
-				deletion[i-1][j-1]+a.scorer.Score(str1[i-1], str2[j-1]),
+				match[i-1][j-1]+letterScore,
+				insetion[i-1][j-1]+letterScore,
+				deletion[i-1][j-1]+letterScore,
 			)
 			insetion[i][j], indexInsertion = MaxOfThreeInt(
-				match[i][j-1]+a.getGapPenalty(i, len(str1), a.gapPenalty),
-				insetion[i][j-1]+a.getGapPenalty(i, len(str1), a.extendGapPenalty),
-				deletion[i][j-1]+a.getGapPenalty(i, len(str1), a.gapPenalty),
+				match[i][j-1]+a.getGapPenalty(i, n, a.gapPenalty),
+				insetion[i][j-1]+a.getGapPenalty(i, n, a.extendGapPenalty),
+				deletion[i][j-1]+a.getGapPenalty(i, n, a.gapPenalty),
 			)
 			deletion[i][j], indexDeletion = MaxOfThreeInt(
-				match[i-1][j]+a.getGapPenalty(j, len(str2), a.gapPenalty),
-				insetion[i-1][j]+a.getGapPenalty(j, len(str2), a.gapPenalty),
-				deletion[i-1][j]+a.getGapPenalty(j, len(str2), a.extendGapPenalty),
+				match[i-1][j]+a.getGapPenalty(j, m, a.gapPenalty),
+				insetion[i-1][j]+a.getGapPenalty(j, m, a.gapPenalty),
+				deletion[i-1][j]+a.getGapPenalty(j, m, a.extendGapPenalty),
 			)
 
 			actions[i][j] = byte(indexDeletion)<<4 | byte(indexInsertion)<<2 | byte(indexMatch)
 		}
 	}
+	if a.dump != nil {
+		a.dump.Matrices = append(a.dump.Matrices,
+			newDPMatrix("match", match, extendTraceback(actions, 0)),
+			newDPMatrix("insertion", insetion, extendTraceback(actions, 1)),
+			newDPMatrix("deletion", deletion, extendTraceback(actions, 2)),
+		)
+	}
 
-	score, index := MaxOfThreeInt(match[len(str1)][len(str2)], insetion[len(str1)][len(str2)], deletion[len(str1)][len(str2)])
-	return actions, action(index), score
+	res, index := MaxOfThreeInt(match[n][m], insetion[n][m], deletion[n][m])
+	return actions, action(index), res
 }
 
 func (a *SequenceAlignerExtend) buildExtendMatrices(rowCount, colCount int) ([][]int, [][]int, [][]int, [][]byte) {
@@ -105,7 +133,7 @@ func (a *SequenceAlignerExtend) buildExtendMatrices(rowCount, colCount int) ([][
 		actions[i] = make([]byte, colCount)
 	}
 
-	infinity := 2*a.gapPenalty + (rowCount+colCount)*a.extendGapPenalty + 1
+	infinity := a.penalty(2*a.gapPenalty+(rowCount+colCount)*a.extendGapPenalty) + 1
 	match[0][0] = 0
 	insetion[0][0] = infinity
 	deletion[0][0] = infinity
@@ -140,5 +168,5 @@ func (a *SequenceAlignerExtend) getGapPenalty(i, max, potentialPenalty int) int
 		return 0
 	}
 
-	return potentialPenalty
+	return a.penalty(potentialPenalty)
 }
@@ -134,3 +134,13 @@ func (s *SequenceAlignerExtendTestSuite) TestAlign() {
 func TestSequenceAlignerExtendSuite(t *testing.T) {
 	suite.Run(t, new(SequenceAlignerExtendTestSuite))
 }
+
+func BenchmarkSequenceAlignerExtend(b *testing.B) {
+	cfg := &SequenceAlignerExtendConfig{
+		SequenceAlignerConfig: SequenceAlignerConfig{
+			GapPenalty: -10,
+		},
+		ExtendGapPenalty: -1,
+	}
+	benchmarkAligner(b, NewSequenceAlignerExtend(cfg, NewDNAAdapter()))
+}
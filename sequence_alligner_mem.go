@@ -1,9 +1,5 @@
 package main
 
-import (
-	"strings"
-)
-
 type coord struct {
 	i int
 	j int
@@ -31,42 +27,35 @@ func NewSequenceAlignerMem(cfg *SequenceAlignerConfig, scorer Scorer) *SequenceA
 
 // Align производит оптимальное глобальное выравнивание двух последовательностей
 func (a *SequenceAlignerMem) Align(str1, str2 string) (string, string, int) {
-	a.upBuffer = make([]int, len(str2)+1)
-	a.downBuffer = make([]int, len(str2)+1)
-
-	actions, score := a.findActions(str1, str2, &coord{0, 0}, &coord{len(str1), len(str2)})
-	alignedStr1, alignedStr2 := &strings.Builder{}, &strings.Builder{}
-
-	i, j, actionIndex := 0, 0, 0
-	for i < len(str1) || j < len(str2) {
-		switch actions[actionIndex] {
-		case letterAction:
-			alignedStr1.WriteByte(str1[i])
-			alignedStr2.WriteByte(str2[j])
-			i++
-			j++
-		case firstGapAction:
-			alignedStr1.WriteByte(gapByte)
-			alignedStr2.WriteByte(str2[j])
-			j++
-		case secondGapAction:
-			alignedStr1.WriteByte(str1[i])
-			alignedStr2.WriteByte(gapByte)
-			i++
-		}
-		actionIndex++
-	}
+	res := a.alignStrings(str1, str2, nil, nil, a.align)
+	return res.Aligned1, res.Aligned2, res.Score
+}
 
-	return alignedStr1.String(), alignedStr2.String(), score
+// AlignDetailed производит выравнивание и возвращает его вместе с координатами.
+// Оценка совмещения i-го и j-го символов умножается на w1[i]*w2[j], nil вместо весов означает, что все веса равны 1.
+// Если число весов не совпадает с числом символов, возвращается ErrWeightsLength.
+func (a *SequenceAlignerMem) AlignDetailed(str1, str2 string, w1, w2 []float64) (*AlignResult, error) {
+	return a.alignWeighted(str1, str2, w1, w2, a.align)
+}
+
+func (a *SequenceAlignerMem) align(n, m int, score scoreFunc) *Alignment {
+	a.upBuffer = make([]int, m+1)
+	a.downBuffer = make([]int, m+1)
+
+	ops, res := a.findOperations(score, n, m, &coord{0, 0}, &coord{n, m})
+	return &Alignment{
+		Operations: ops,
+		Score:      res,
+	}
 }
 
-func (a *SequenceAlignerMem) findActions(str1, str2 string, f, t *coord) ([]action, int) {
+func (a *SequenceAlignerMem) findOperations(score scoreFunc, n, m int, f, t *coord) ([]Operation, int) {
 	if f.i == t.i {
 		score := 0
-		res := make([]action, t.j-f.j)
+		res := make([]Operation, t.j-f.j)
 		for i := 0; i < t.j-f.j; i++ {
-			score += a.getGapPenalty(f.i, len(str1))
-			res[i] = firstGapAction
+			score += a.getGapPenalty(f.i, n)
+			res[i] = OpInsert
 		}
 		return res, score
 	}
@@ -75,38 +64,38 @@ func (a *SequenceAlignerMem) findActions(str1, str2 string, f, t *coord) ([]acti
 	upSize := size / 2
 	downSize := (size - (size+1)%2) / 2
 
-	a.findUp(str1, str2, f, &coord{f.i + upSize, t.j})
-	a.findDown(str1, str2, &coord{t.i - downSize, f.j}, t)
+	a.findUp(score, n, m, f, &coord{f.i + upSize, t.j})
+	a.findDown(score, n, m, &coord{t.i - downSize, f.j}, t)
 
 	i, j := f.i+upSize, f.j
-	act, v := secondGapAction, a.upBuffer[j]+a.downBuffer[j]+a.getGapPenalty(i, len(str1))
+	act, v := OpDelete, a.upBuffer[j]+a.downBuffer[j]+a.getGapPenalty(i, n)
 
 	for k := f.j; k <= t.j; k++ {
-		current := a.upBuffer[k] + a.downBuffer[k] + a.getGapPenalty(k, len(str2))
+		current := a.upBuffer[k] + a.downBuffer[k] + a.getGapPenalty(k, m)
 		if current > v {
 			j, v = k, current
 		}
 	}
 
 	for k := f.j; k < t.j; k++ {
-		current := a.upBuffer[k] + a.downBuffer[k+1] + a.scorer.Score(str1[i], str2[k])
+		current := a.upBuffer[k] + a.downBuffer[k+1] + score(i, k)
 		if current > v {
 			j, v = k, current
-			act = letterAction
+			act = OpMatch
 		}
 	}
 
 	fNext := &coord{i + 1, j}
-	if act == letterAction {
+	if act == OpMatch {
 		fNext.j++
 	}
 
 	tNext := &coord{i, j}
 
-	part1, _ := a.findActions(str1, str2, f, tNext)
-	part2, _ := a.findActions(str1, str2, fNext, t)
+	part1, _ := a.findOperations(score, n, m, f, tNext)
+	part2, _ := a.findOperations(score, n, m, fNext, t)
 
-	res := make([]action, 0)
+	res := make([]Operation, 0)
 	res = append(res, part1...)
 	res = append(res, act)
 	res = append(res, part2...)
@@ -114,20 +103,20 @@ func (a *SequenceAlignerMem) findActions(str1, str2 string, f, t *coord) ([]acti
 	return res, v
 }
 
-func (a *SequenceAlignerMem) findUp(str1, str2 string, f, t *coord) {
+func (a *SequenceAlignerMem) findUp(score scoreFunc, n, m int, f, t *coord) {
 	a.upBuffer[f.j] = 0
 	for j := f.j + 1; j <= t.j; j++ {
-		a.upBuffer[j] = a.upBuffer[j-1] + a.getGapPenalty(f.i, len(str1))
+		a.upBuffer[j] = a.upBuffer[j-1] + a.getGapPenalty(f.i, n)
 	}
 
 	var tmp int
 	for i := f.i; i < t.i; i++ {
-		tmp, a.upBuffer[f.j] = a.upBuffer[f.j], a.upBuffer[f.j]+a.getGapPenalty(f.j, len(str2))
+		tmp, a.upBuffer[f.j] = a.upBuffer[f.j], a.upBuffer[f.j]+a.getGapPenalty(f.j, m)
 		for j := f.j + 1; j <= t.j; j++ {
 			val, _ := MaxOfThreeInt(
-				tmp+a.scorer.Score(str1[i], str2[j-1]),
-				a.upBuffer[j-1]+a.getGapPenalty(i, len(str1)),
-				a.upBuffer[j]+a.getGapPenalty(j, len(str2)),
+				tmp+score(i, j-1),
+				a.upBuffer[j-1]+a.getGapPenalty(i, n),
+				a.upBuffer[j]+a.getGapPenalty(j, m),
 			)
 
 			tmp, a.upBuffer[j] = a.upBuffer[j], val
@@ -135,20 +124,20 @@ func (a *SequenceAlignerMem) findUp(str1, str2 string, f, t *coord) {
 	}
 }
 
-func (a *SequenceAlignerMem) findDown(str1, str2 string, f, t *coord) {
+func (a *SequenceAlignerMem) findDown(score scoreFunc, n, m int, f, t *coord) {
 	a.downBuffer[t.j] = 0
 	for j := t.j - 1; j >= f.j; j-- {
-		a.downBuffer[j] = a.downBuffer[j+1] + a.getGapPenalty(t.i, len(str1))
+		a.downBuffer[j] = a.downBuffer[j+1] + a.getGapPenalty(t.i, n)
 	}
 
 	var tmp int
 	for i := t.i; i > f.i; i-- {
-		tmp, a.downBuffer[t.j] = a.downBuffer[t.j], a.downBuffer[t.j]+a.getGapPenalty(t.j, len(str2))
+		tmp, a.downBuffer[t.j] = a.downBuffer[t.j], a.downBuffer[t.j]+a.getGapPenalty(t.j, m)
 		for j := t.j - 1; j >= f.j; j-- {
 			val, _ := MaxOfThreeInt(
-				tmp+a.scorer.Score(str1[i-1], str2[j]),
-				a.downBuffer[j+1]+a.getGapPenalty(i, len(str1)),
-				a.downBuffer[j]+a.getGapPenalty(j, len(str2)),
+				tmp+score(i-1, j),
+				a.downBuffer[j+1]+a.getGapPenalty(i, n),
+				a.downBuffer[j]+a.getGapPenalty(j, m),
 			)
 
 			tmp, a.downBuffer[j] = a.downBuffer[j], val
@@ -151,3 +151,10 @@ func (s *SequenceAlignerMemTestSuite) TestAlign() {
 func TestSequenceAlignerMemSuite(t *testing.T) {
 	suite.Run(t, new(SequenceAlignerMemTestSuite))
 }
+
+func BenchmarkSequenceAlignerMem(b *testing.B) {
+	cfg := &SequenceAlignerConfig{
+		GapPenalty: -10,
+	}
+	benchmarkAligner(b, NewSequenceAlignerMem(cfg, NewDNAAdapter()))
+}
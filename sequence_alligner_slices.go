@@ -0,0 +1,58 @@
+package main
+
+// MatchScore возвращает функцию оценки, дающую match за равные элементы и mismatch за различные
+func MatchScore[T comparable](match, mismatch int) func(T, T) int {
+	return func(a, b T) int {
+		if a == b {
+			return match
+		}
+		return mismatch
+	}
+}
+
+// AlignSlices производит оптимальное глобальное (или локальное, если cfg.AllowLocal) выравнивание
+// двух последовательностей произвольных элементов тем же ядром, что и SequenceAligner.
+// Элементы оцениваются функцией score.
+func AlignSlices[T comparable](a, b []T, score func(T, T) int, cfg *SequenceAlignerConfig) *Alignment {
+	return NewSequenceAligner(cfg, nil).align(len(a), len(b), sliceScore(a, b, score))
+}
+
+// AlignSlicesExtend производит оптимальное глобальное выравнивание двух последовательностей
+// произвольных элементов с разным штрафом за открытие и расширение gap тем же ядром, что и SequenceAlignerExtend.
+// Элементы оцениваются функцией score.
+func AlignSlicesExtend[T comparable](a, b []T, score func(T, T) int, cfg *SequenceAlignerExtendConfig) *Alignment {
+	return NewSequenceAlignerExtend(cfg, nil).align(len(a), len(b), sliceScore(a, b, score))
+}
+
+// ApplyAlignment строит выровненные последовательности по операциям выравнивания,
+// на месте gap записывается элемент gap.
+func ApplyAlignment[T any](res *Alignment, a, b []T, gap T) ([]T, []T) {
+	aligned1, aligned2 := make([]T, 0, len(res.Operations)), make([]T, 0, len(res.Operations))
+
+	i, j := res.Start1, res.Start2
+	for _, op := range res.Operations {
+		switch op {
+		case OpMatch:
+			aligned1 = append(aligned1, a[i])
+			aligned2 = append(aligned2, b[j])
+			i++
+			j++
+		case OpInsert:
+			aligned1 = append(aligned1, gap)
+			aligned2 = append(aligned2, b[j])
+			j++
+		case OpDelete:
+			aligned1 = append(aligned1, a[i])
+			aligned2 = append(aligned2, gap)
+			i++
+		}
+	}
+
+	return aligned1, aligned2
+}
+
+func sliceScore[T any](a, b []T, score func(T, T) int) scoreFunc {
+	return func(i, j int) int {
+		return score(a[i], b[j])
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type AlignSlicesTestSuite struct {
+	suite.Suite
+}
+
+func (s *AlignSlicesTestSuite) TestAlignWords() {
+	a := strings.Fields("the quick brown fox jumps over the lazy dog")
+	b := strings.Fields("the brown fox jumped over the dog")
+	cfg := &SequenceAlignerConfig{
+		GapPenalty:      -1,
+		GapStartPenalty: true,
+		GapEndPenalty:   true,
+	}
+
+	res := AlignSlices(a, b, MatchScore[string](2, -1), cfg)
+	s.Equal([]Operation{OpMatch, OpDelete, OpMatch, OpMatch, OpMatch, OpMatch, OpMatch, OpDelete, OpMatch}, res.Operations)
+	s.Equal(9, res.Score)
+
+	aligned1, aligned2 := ApplyAlignment(res, a, b, "-")
+	s.Equal(strings.Fields("the quick brown fox jumps over the lazy dog"), aligned1)
+	s.Equal(strings.Fields("the - brown fox jumped over the - dog"), aligned2)
+}
+
+func (s *AlignSlicesTestSuite) TestAlignLocal() {
+	a := []int{9, 9, 1, 2, 3, 9}
+	b := []int{7, 1, 2, 3, 7, 7}
+	cfg := &SequenceAlignerConfig{
+		AllowLocal: true,
+		GapPenalty: -2,
+	}
+
+	res := AlignSlices(a, b, MatchScore[int](1, -1), cfg)
+	s.Equal([]Operation{OpMatch, OpMatch, OpMatch}, res.Operations)
+	s.Equal(3, res.Score)
+	s.Equal(2, res.Start1)
+	s.Equal(1, res.Start2)
+}
+
+func (s *AlignSlicesTestSuite) TestAlignExtendMatchesStrings() {
+	cfg := &SequenceAlignerExtendConfig{
+		SequenceAlignerConfig: SequenceAlignerConfig{
+			GapPenalty:      -10,
+			GapStartPenalty: true,
+			GapEndPenalty:   true,
+		},
+		ExtendGapPenalty: -1,
+	}
+	adapter := NewDNAAdapter()
+	str1, str2 := "ATGCCC", "ATTTCCCC"
+
+	res := AlignSlicesExtend([]byte(str1), []byte(str2), adapter.Score, cfg)
+	aligned1, aligned2 := ApplyAlignment(res, []byte(str1), []byte(str2), gapByte)
+
+	expA, expB, expScore := NewSequenceAlignerExtend(cfg, adapter).Align(str1, str2)
+	s.Equal(expA, string(aligned1))
+	s.Equal(expB, string(aligned2))
+	s.Equal(expScore, res.Score)
+}
+
+func TestAlignSlicesSuite(t *testing.T) {
+	suite.Run(t, new(AlignSlicesTestSuite))
+}
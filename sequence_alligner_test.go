@@ -1,8 +1,11 @@
 package main
 
 import (
+	"fmt"
+	"math/rand"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -148,6 +151,136 @@ func (s *SequenceAlignerTestSuite) TestAlign() {
 	}
 }
 
+func (s *SequenceAlignerTestSuite) TestAlignRunes() {
+	cfg := &SequenceAlignerConfig{
+		GapPenalty:      -2,
+		GapStartPenalty: true,
+		GapEndPenalty:   true,
+	}
+	adapter := NewDefaultAdapter(1, -1)
+	extendCfg := &SequenceAlignerExtendConfig{SequenceAlignerConfig: *cfg, ExtendGapPenalty: -2}
+
+	for _, aligner := range []Aligner{
+		NewSequenceAligner(cfg, adapter),
+		NewSequenceAlignerExtend(extendCfg, adapter),
+		NewSequenceAlignerMem(cfg, adapter),
+	} {
+		// многобайтовые символы выравниваются целиком
+		a, b, score := aligner.Align("привет", "приветик")
+		s.Equal("привет--", a)
+		s.Equal("приветик", b)
+		s.Equal(2, score)
+
+		a, b, score = aligner.Align("🦄🌈⭐", "🦄⭐")
+		s.Equal("🦄🌈⭐", a)
+		s.Equal("🦄-⭐", b)
+		s.Equal(0, score)
+	}
+}
+
+func (s *SequenceAlignerTestSuite) TestAlignWeighted() {
+	s.aligner.gapStartPenalty = true
+	s.aligner.gapEndPenalty = true
+
+	// совпадение с плохо прочитанным символом почти ничего не даёт
+	res, err := s.aligner.AlignDetailed("ACGT", "ACGT", []float64{1, 1, 0.1, 1}, nil)
+	s.Require().NoError(err)
+	s.Equal("ACGT", res.Aligned1)
+	s.Equal("ACGT", res.Aligned2)
+	s.Equal(16, res.Score)
+
+	// без весов результат совпадает с Align
+	res = alignDetailed(s.aligner, "AATCG", "AACG")
+	s.Equal("AATCG", res.Aligned1)
+	s.Equal("AA-CG", res.Aligned2)
+	s.Equal(10, res.Score)
+}
+
+func (s *SequenceAlignerTestSuite) TestAlignWeightedSmallWeights() {
+	cfg := &SequenceAlignerConfig{GapPenalty: -2, GapStartPenalty: true, GapEndPenalty: true}
+	for _, aligner := range []Aligner{
+		NewSequenceAligner(cfg, NewDefaultAdapter(1, -1)),
+		NewSequenceAlignerExtend(&SequenceAlignerExtendConfig{*cfg, -1}, NewDefaultAdapter(1, -1)),
+		NewSequenceAlignerMem(cfg, NewDefaultAdapter(1, -1)),
+	} {
+		// веса меньше 0.5 не обнуляют оценки отдельных совмещений: 4 * 0.4 = 1.6
+		w := []float64{0.4, 0.4, 0.4, 0.4}
+		res, err := aligner.AlignDetailed("AAAA", "AAAA", w, nil)
+		s.Require().NoError(err)
+		s.Equal(2, res.Score)
+
+		// более качественный символ выигрывает, хотя оба веса меньше 0.5
+		res, err = aligner.AlignDetailed("AA", "A", []float64{0.3, 0.4}, nil)
+		s.Require().NoError(err)
+		s.Equal("-A", res.Aligned2)
+		s.Equal(-2, res.Score)
+	}
+}
+
+func (s *SequenceAlignerTestSuite) TestAlignWeightsLength() {
+	_, err := s.aligner.AlignDetailed("ACGT", "ACGT", []float64{1, 1}, nil)
+	s.Equal(ErrWeightsLength, errors.Cause(err))
+	_, err = s.aligner.AlignDetailed("ACGT", "ACG", nil, []float64{1, 1, 1, 1})
+	s.Equal(ErrWeightsLength, errors.Cause(err))
+
+	// при выравнивании по рунам вес задаётся для каждой руны, а не для каждого байта
+	runes := NewSequenceAligner(&SequenceAlignerConfig{GapPenalty: -2}, NewDefaultAdapter(1, -1))
+	res, err := runes.AlignDetailed("хорошо", "хорош", []float64{1, 1, 1, 1, 1, 0.5}, nil)
+	s.Require().NoError(err)
+	s.Equal("хорош-", res.Aligned2)
+	_, err = runes.AlignDetailed("хорошо", "хорош", make([]float64, len("хорошо")), nil)
+	s.Equal(ErrWeightsLength, errors.Cause(err))
+
+	// DNAAdapter не оценивает руны, поэтому строки выравниваются по байтам
+	dna := NewSequenceAligner(&SequenceAlignerConfig{GapPenalty: -2}, NewDNAAdapter())
+	_, err = dna.AlignDetailed("AЖ", "A", []float64{1, 1}, nil)
+	s.Equal(ErrWeightsLength, errors.Cause(err))
+	_, err = dna.AlignDetailed("AЖ", "A", []float64{1, 1, 1}, nil)
+	s.NoError(err)
+}
+
+// alignDetailed выравнивает строки без весов, в этом случае AlignDetailed не возвращает ошибок
+func alignDetailed(aligner Aligner, str1, str2 string) *AlignResult {
+	res, err := aligner.AlignDetailed(str1, str2, nil, nil)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
 func TestSequenceAlignerSuite(t *testing.T) {
 	suite.Run(t, new(SequenceAlignerTestSuite))
 }
+
+// benchmarkSizes длины последовательностей, на которых измеряется скорость выравнивания
+var benchmarkSizes = []int{1000, 5000, 10000}
+
+// randomDNA возвращает псевдослучайную последовательность нуклеотидов длины n
+func randomDNA(rnd *rand.Rand, n int) string {
+	const alphabet = "ATGC"
+	res := make([]byte, n)
+	for i := range res {
+		res[i] = alphabet[rnd.Intn(len(alphabet))]
+	}
+	return string(res)
+}
+
+// benchmarkAligner измеряет скорость выравнивания двух случайных последовательностей каждой длины из benchmarkSizes
+func benchmarkAligner(b *testing.B, aligner Aligner) {
+	rnd := rand.New(rand.NewSource(42))
+	for _, n := range benchmarkSizes {
+		str1, str2 := randomDNA(rnd, n), randomDNA(rnd, n)
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				aligner.Align(str1, str2)
+			}
+		})
+	}
+}
+
+func BenchmarkSequenceAligner(b *testing.B) {
+	cfg := &SequenceAlignerConfig{
+		GapPenalty: -10,
+	}
+	benchmarkAligner(b, NewSequenceAligner(cfg, NewDNAAdapter()))
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"unicode"
+)
+
+// AlignmentStats статистика колонок выравнивания
+type AlignmentStats struct {
+	// Length число колонок выравнивания
+	Length     int
+	Identity   int
+	Similarity int
+	Mismatch   int
+	Gaps       int
+	// GapOpens число непрерывных участков gap в каждой из строк
+	GapOpens int
+	// LongestGap длина самого длинного непрерывного участка gap
+	LongestGap int
+	// Start1, End1, Start2 и End2 границы (с 0, конец не включается) выровненных участков последовательностей
+	// без концевых gap. Если совмещённых символов нет, то участки пусты.
+	Start1 int
+	End1   int
+	Start2 int
+	End2   int
+	// Transitions и Transversions число замен нуклеотидов пурин-пурин или пиримидин-пиримидин
+	// и пурин-пиримидин соответственно. Прочие символы не учитываются.
+	Transitions   int
+	Transversions int
+}
+
+// ComputeStats подсчитывает статистику выравнивания res, полученного любым Aligner.
+// Похожими считаются различные символы с положительной оценкой scorer, nil scorer не находит похожих.
+func ComputeStats(res *AlignResult, scorer Scorer) *AlignmentStats {
+	runes1, runes2 := []rune(res.Aligned1), []rune(res.Aligned2)
+	trimmed := res.trimGaps()
+	s := &AlignmentStats{
+		Length: len(runes1),
+		Start1: trimmed.Start1,
+		End1:   trimmed.End1,
+		Start2: trimmed.Start2,
+		End2:   trimmed.End2,
+	}
+	// gap1 и gap2 длины текущих участков gap в каждой из строк
+	gap1, gap2 := 0, 0
+	for i := range runes1 {
+		if runes1[i] == gapRune {
+			gap1++
+		} else {
+			gap1 = 0
+		}
+		if runes2[i] == gapRune {
+			gap2++
+		} else {
+			gap2 = 0
+		}
+
+		switch markupSymbol(scorer, runes1[i], runes2[i]) {
+		case markupIdentity:
+			s.Identity++
+			s.Similarity++
+		case markupSimilar:
+			s.Similarity++
+			s.Mismatch++
+			s.countSubstitution(runes1[i], runes2[i])
+		case markupMismatch:
+			s.Mismatch++
+			s.countSubstitution(runes1[i], runes2[i])
+		case markupGap:
+			s.Gaps++
+			if gap1 == 1 {
+				s.GapOpens++
+			}
+			if gap2 == 1 {
+				s.GapOpens++
+			}
+			s.LongestGap = MaxInt(s.LongestGap, MaxInt(gap1, gap2))
+		}
+	}
+	return s
+}
+
+// countSubstitution учитывает замену нуклеотида a на b как транзицию или трансверсию
+func (s *AlignmentStats) countSubstitution(a, b rune) {
+	class1, class2 := nucleotideClass(a), nucleotideClass(b)
+	switch {
+	case class1 == 0 || class2 == 0:
+	case class1 == class2:
+		s.Transitions++
+	default:
+		s.Transversions++
+	}
+}
+
+// nucleotideClass возвращает 'R' для пуринов, 'Y' для пиримидинов и 0 для прочих символов
+func nucleotideClass(r rune) rune {
+	switch unicode.ToUpper(r) {
+	case 'A', 'G':
+		return 'R'
+	case 'C', 'T', 'U':
+		return 'Y'
+	}
+	return 0
+}
+
+// IdentityPercent доля совпадений среди колонок в процентах
+func (s *AlignmentStats) IdentityPercent() float64 {
+	return fraction(s.Identity, s.Length)
+}
+
+// SimilarityPercent доля похожих символов (включая совпадения) среди колонок в процентах
+func (s *AlignmentStats) SimilarityPercent() float64 {
+	return fraction(s.Similarity, s.Length)
+}
+
+// GapsPercent доля колонок с gap в процентах
+func (s *AlignmentStats) GapsPercent() float64 {
+	return fraction(s.Gaps, s.Length)
+}
+
+// TsTvRatio возвращает отношение числа транзиций к числу трансверсий.
+// Если трансверсий нет, то отношение не определено и ok ложно.
+func (s *AlignmentStats) TsTvRatio() (ratio float64, ok bool) {
+	if s.Transversions == 0 {
+		return 0, false
+	}
+	return float64(s.Transitions) / float64(s.Transversions), true
+}
+
+// Write выводит статистику в текстовом виде, отношение транзиций к трансверсиям выводится, только если dna
+func (s *AlignmentStats) Write(w io.Writer, dna bool) error {
+	lines := []string{
+		fmt.Sprintf("Length: %d", s.Length),
+		fmt.Sprintf("Identity: %d/%d (%s)", s.Identity, s.Length, percent(s.Identity, s.Length)),
+		fmt.Sprintf("Similarity: %d/%d (%s)", s.Similarity, s.Length, percent(s.Similarity, s.Length)),
+		fmt.Sprintf("Gaps: %d/%d (%s)", s.Gaps, s.Length, percent(s.Gaps, s.Length)),
+		fmt.Sprintf("Gap openings: %d", s.GapOpens),
+		fmt.Sprintf("Longest gap: %d", s.LongestGap),
+		fmt.Sprintf("Seq1 range: %s", statsRange(s.Start1, s.End1)),
+		fmt.Sprintf("Seq2 range: %s", statsRange(s.Start2, s.End2)),
+	}
+	if dna {
+		ratio := "n/a"
+		if r, ok := s.TsTvRatio(); ok {
+			ratio = fmt.Sprintf("%.2f", r)
+		}
+		lines = append(lines, fmt.Sprintf("Ts/Tv: %s (%d/%d)", ratio, s.Transitions, s.Transversions))
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statsRange возвращает участок [start, end) в виде номеров первого и последнего символа (с 1)
+func statsRange(start, end int) string {
+	if start >= end {
+		return "-"
+	}
+	return fmt.Sprintf("%d-%d", start+1, end)
+}
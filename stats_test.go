@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type StatsTestSuite struct {
+	suite.Suite
+}
+
+func (s *StatsTestSuite) TestComputeStats() {
+	res := &AlignResult{Aligned1: "-ACGTAC--GA", Aligned2: "TATG---CAGT", End1: 8, End2: 8, Len1: 8, Len2: 8}
+	stats := ComputeStats(res, NewDNAAdapter())
+
+	s.Equal(11, stats.Length)
+	s.Equal(3, stats.Identity)
+	s.Equal(3, stats.Similarity)
+	s.Equal(2, stats.Mismatch)
+	s.Equal(6, stats.Gaps)
+	s.Equal(3, stats.GapOpens)
+	s.Equal(3, stats.LongestGap)
+	s.Equal([]int{0, 8, 1, 8}, []int{stats.Start1, stats.End1, stats.Start2, stats.End2})
+	// C-T транзиция, A-T трансверсия
+	s.Equal(1, stats.Transitions)
+	s.Equal(1, stats.Transversions)
+
+	ratio, ok := stats.TsTvRatio()
+	s.True(ok)
+	s.Equal(1.0, ratio)
+	s.InDelta(100*3.0/11, stats.IdentityPercent(), 1e-9)
+
+	_, ok = ComputeStats(&AlignResult{Aligned1: "AC", Aligned2: "GC"}, nil).TsTvRatio()
+	s.False(ok)
+}
+
+func (s *StatsTestSuite) TestSimilarity() {
+	stats := ComputeStats(&AlignResult{Aligned1: "AWGHEI", Aligned2: "AW-HEV", End1: 6, End2: 5, Len1: 6, Len2: 5}, NewProteinAdapterBLOSUM62())
+	s.Equal(4, stats.Identity)
+	s.Equal(5, stats.Similarity)
+	s.Equal(0, stats.Transitions+stats.Transversions)
+}
+
+func (s *StatsTestSuite) TestWrite() {
+	res := &AlignResult{Aligned1: "ACGT-", Aligned2: "GCGTA", End1: 4, End2: 5, Len1: 4, Len2: 5}
+	var buf bytes.Buffer
+	s.Require().NoError(ComputeStats(res, NewDNAAdapter()).Write(&buf, true))
+	s.Equal(""+
+		"Length: 5\n"+
+		"Identity: 3/5 (60.0%)\n"+
+		"Similarity: 3/5 (60.0%)\n"+
+		"Gaps: 1/5 (20.0%)\n"+
+		"Gap openings: 1\n"+
+		"Longest gap: 1\n"+
+		"Seq1 range: 1-4\n"+
+		"Seq2 range: 1-4\n"+
+		"Ts/Tv: n/a (1/0)\n", buf.String())
+}
+
+func TestStatsSuite(t *testing.T) {
+	suite.Run(t, new(StatsTestSuite))
+}
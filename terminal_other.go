@@ -0,0 +1,11 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package main
+
+import "os"
+
+// terminalWidth возвращает 0: ширина терминала на этой платформе не определяется
+func terminalWidth(f *os.File) int {
+	return 0
+}